@@ -5,9 +5,12 @@ package main
 
 import (
 	cr "github.com/danielmichaels/check-redirects-bonzai"
+	"github.com/danielmichaels/ds/pkg/doctor"
 	"github.com/danielmichaels/ds/pkg/get"
 	"github.com/danielmichaels/ds/pkg/install"
 	"github.com/danielmichaels/ds/pkg/scripts"
+	"github.com/danielmichaels/ds/pkg/shellenv"
+	"github.com/danielmichaels/ds/pkg/web"
 	"github.com/danielmichaels/zet-cmd"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/conf"
@@ -29,6 +32,7 @@ func init() {
 	if err != nil {
 		panic("system error initializing vars")
 	}
+	web.UserAgent = "ds/" + MakeVersion()
 }
 
 func main() {
@@ -54,12 +58,14 @@ var Cmd = &Z.Cmd{
 		"uuid":   {"uniq", "uuid"},
 		"isosec": {"uniq", "isosec"},
 		"env":    {"scripts", "env-check"},
+		"exec":   {"get", "exec"},
+		"run":    {"get", "run"},
 	},
 	Commands: []*Z.Cmd{
 		// imported
 		h.Cmd, conf.Cmd, yq.Cmd, vars.Cmd, y2j.Cmd, vars.Cmd, uniq.Cmd, zet.Cmd,
 		// internal
-		scripts.Cmd, install.Cmd, get.Cmd, cr.Cmd,
+		scripts.Cmd, install.Cmd, get.Cmd, get.SelfUpdate, shellenv.Cmd, doctor.Cmd, cr.Cmd,
 	},
 	Issues: `github.com/danielmichaels/ds/issues`,
 	Site:   `danielms.site`,
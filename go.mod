@@ -3,25 +3,33 @@ module github.com/danielmichaels/ds
 go 1.18
 
 require (
+	github.com/ProtonMail/go-crypto v0.0.0-20221026131551-cf6655e29de4
+	github.com/charmbracelet/glamour v0.5.0
 	github.com/danielmichaels/check-redirects-bonzai v0.0.1
 	github.com/danielmichaels/zet-cmd v0.2.1
+	github.com/klauspost/compress v1.15.12
 	github.com/olekukonko/tablewriter v0.0.5
 	github.com/rwxrob/bonzai v0.20.2
 	github.com/rwxrob/conf v0.8.0
 	github.com/rwxrob/help v0.7.0
 	github.com/rwxrob/json v0.7.0
+	github.com/rwxrob/term v0.2.8
 	github.com/rwxrob/uniq v0.2.1
 	github.com/rwxrob/vars v0.4.1
 	github.com/rwxrob/y2j v0.4.0
 	github.com/rwxrob/yq v0.3.0
 	github.com/schollz/progressbar/v3 v3.11.0
+	github.com/ulikunitz/xz v0.5.11
+	golang.org/x/crypto v0.3.0
+	golang.org/x/sys v0.2.0
+	gopkg.in/yaml.v3 v3.0.0
 )
 
 require (
 	github.com/a8m/envsubst v1.3.0 // indirect
 	github.com/alecthomas/chroma v0.10.0 // indirect
 	github.com/aymerick/douceur v0.2.0 // indirect
-	github.com/charmbracelet/glamour v0.5.0 // indirect
+	github.com/cloudflare/circl v1.1.0 // indirect
 	github.com/dlclark/regexp2 v1.4.0 // indirect
 	github.com/elliotchance/orderedmap v1.4.0 // indirect
 	github.com/fatih/color v1.13.0 // indirect
@@ -46,18 +54,14 @@ require (
 	github.com/rwxrob/fs v0.5.2 // indirect
 	github.com/rwxrob/pegn v0.1.0 // indirect
 	github.com/rwxrob/structs v0.6.0 // indirect
-	github.com/rwxrob/term v0.2.8 // indirect
 	github.com/rwxrob/to v0.11.3 // indirect
 	github.com/timtadh/data-structures v0.5.3 // indirect
 	github.com/timtadh/lexmachine v0.2.2 // indirect
 	github.com/yuin/goldmark v1.4.4 // indirect
 	github.com/yuin/goldmark-emoji v1.0.1 // indirect
-	golang.org/x/crypto v0.3.0 // indirect
 	golang.org/x/net v0.2.0 // indirect
-	golang.org/x/sys v0.2.0 // indirect
 	golang.org/x/term v0.2.0 // indirect
 	golang.org/x/text v0.4.0 // indirect
 	golang.org/x/xerrors v0.0.0-20220517211312-f3a8303e98df // indirect
 	gopkg.in/op/go-logging.v1 v1.0.0-20160211212156-b2cb9fa56473 // indirect
-	gopkg.in/yaml.v3 v3.0.0 // indirect
 )
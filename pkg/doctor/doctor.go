@@ -0,0 +1,338 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+package doctor
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/get"
+	"github.com/danielmichaels/ds/pkg/scripts"
+	"github.com/danielmichaels/ds/pkg/web"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"github.com/rwxrob/term"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// Result is the outcome of a single check. Fix suggests how to resolve a
+// warning or failure.
+type Result struct {
+	Status  string
+	Message string
+	Fix     string
+}
+
+// Check runs one area of checks and returns a result for each thing it
+// looked at.
+type Check struct {
+	Name string
+	Run  func() []Result
+}
+
+// Checks are run by the doctor command in order.
+var Checks = []Check{
+	{"path", checkPath},
+	{"installed", checkInstalled},
+	{"conf", checkConf},
+	{"github", checkGithub},
+	{"dependencies", checkDependencies},
+}
+
+var Cmd = &Z.Cmd{
+	Name:     `doctor`,
+	Summary:  `check the ds environment for problems`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *doctor* command checks that the ds bin directory is on PATH, that
+		installed tools are intact, built for this platform and not shadowed
+		by other binaries, that configuration used by commands is present,
+		the GitHub API rate limit and that programs used by the *scripts*
+		commands are installed.
+
+		Each check prints a pass, warn or fail line with a suggested fix. The
+		command fails if any check fails.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		var results []Result
+		for _, c := range Checks {
+			results = append(results, c.Run()...)
+		}
+		failed := Print(os.Stdout, results)
+		if failed > 0 {
+			return fmt.Errorf("%d checks failed", failed)
+		}
+		return nil
+	},
+}
+
+// labels are the coloured status labels printed by Print.
+var labels = map[string]string{
+	Pass: term.Green + "PASS" + term.Reset,
+	Warn: term.Yellow + "WARN" + term.Reset,
+	Fail: term.Red + "FAIL" + term.Reset,
+}
+
+// Print writes the results to w and returns the number that failed. The
+// status labels are coloured only when get.UseColor allows it.
+func Print(w io.Writer, results []Result) int {
+	counts := map[string]int{}
+	color := get.UseColor()
+	for _, r := range results {
+		counts[r.Status]++
+		label := labels[r.Status]
+		if !color {
+			label = strings.ToUpper(r.Status)
+		}
+		fmt.Fprintf(w, "%s  %s\n", label, r.Message)
+		if r.Fix != "" && r.Status != Pass {
+			fmt.Fprintf(w, "      fix: %s\n", r.Fix)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed\n", counts[Pass], counts[Warn], counts[Fail])
+	return counts[Fail]
+}
+
+func checkPath() []Result {
+	bin := get.BinDir()
+	if get.OnPath(bin) {
+		return []Result{{Status: Pass, Message: bin + " is on PATH"}}
+	}
+	return []Result{{
+		Status:  Warn,
+		Message: bin + " is not on PATH",
+		Fix:     `add eval "$(ds shellenv)" to your shell rc file or run ds shellenv --install`,
+	}}
+}
+
+func checkInstalled() []Result {
+	st, err := get.LoadState()
+	if err != nil {
+		return []Result{{Status: Fail, Message: "reading " + get.StateFile() + ": " + err.Error(), Fix: "remove the file and reinstall your tools"}}
+	}
+	list := st.List()
+	if len(list) == 0 {
+		return []Result{{Status: Pass, Message: "no tools installed by ds"}}
+	}
+	var results []Result
+	for _, in := range list {
+		results = append(results, checkTool(in))
+	}
+	return results
+}
+
+// checkTool checks that an installed tool is intact, runs on this platform
+// and is the one found on PATH.
+func checkTool(in get.Installed) Result {
+	reinstall := "reinstall it with ds get " + in.Name
+	if err := in.Check(); err != nil {
+		return Result{Status: Fail, Message: in.Name + ": " + err.Error(), Fix: reinstall}
+	}
+	goos, goarch, err := binaryPlatform(in.Path)
+	if err != nil {
+		return Result{Status: Fail, Message: in.Name + ": " + err.Error(), Fix: reinstall}
+	}
+	if (goos != "" && goos != runtime.GOOS) || (goarch != "" && goarch != runtime.GOARCH) {
+		return Result{
+			Status:  Fail,
+			Message: fmt.Sprintf("%s is built for %s/%s, not %s/%s", in.Name, goos, goarch, runtime.GOOS, runtime.GOARCH),
+			Fix:     reinstall,
+		}
+	}
+	if found := shadowedBy(in); found != "" {
+		return Result{
+			Status:  Warn,
+			Message: fmt.Sprintf("%s %s is shadowed by %s", in.Name, in.Version, found),
+			Fix:     fmt.Sprintf("remove %s or put %s earlier in PATH", found, filepath.Dir(in.Path)),
+		}
+	}
+	return Result{Status: Pass, Message: fmt.Sprintf("%s %s is installed", in.Name, in.Version)}
+}
+
+// shadowedBy returns the binary run instead of the installed tool, or an
+// empty string when the installed one is found first or not on PATH at
+// all.
+func shadowedBy(in get.Installed) string {
+	found, err := exec.LookPath(in.Name)
+	if err != nil || !get.OnPath(filepath.Dir(in.Path)) {
+		return ""
+	}
+	a, _ := filepath.EvalSymlinks(found)
+	b, _ := filepath.EvalSymlinks(in.Path)
+	if a == b {
+		return ""
+	}
+	return found
+}
+
+// binaryPlatform returns the GOOS and GOARCH an executable was built for.
+// Both are empty for scripts and other files that are not ELF, Mach-O or PE
+// executables.
+func binaryPlatform(file string) (goos, goarch string, err error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", "", err
+	}
+	defer f.Close()
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return "", "", nil
+	}
+
+	switch {
+	case bytes.Equal(magic, []byte("\x7fELF")):
+		ef, err := elf.NewFile(f)
+		if err != nil {
+			return "", "", fmt.Errorf("corrupt ELF binary: %w", err)
+		}
+		return "linux", map[elf.Machine]string{
+			elf.EM_X86_64:  "amd64",
+			elf.EM_AARCH64: "arm64",
+			elf.EM_ARM:     "arm",
+			elf.EM_386:     "386",
+		}[ef.Machine], nil
+	case bytes.Equal(magic[:2], []byte("MZ")):
+		pf, err := pe.NewFile(f)
+		if err != nil {
+			return "", "", fmt.Errorf("corrupt PE binary: %w", err)
+		}
+		return "windows", map[uint16]string{
+			pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+			pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+			pe.IMAGE_FILE_MACHINE_I386:  "386",
+		}[pf.Machine], nil
+	case bytes.Equal(magic, []byte{0xca, 0xfe, 0xba, 0xbe}):
+		ff, err := macho.NewFatFile(f)
+		if err != nil {
+			return "", "", fmt.Errorf("corrupt Mach-O binary: %w", err)
+		}
+		// A universal binary runs on any architecture it contains.
+		for _, a := range ff.Arches {
+			if machoArch(a.Cpu) == runtime.GOARCH {
+				return "darwin", runtime.GOARCH, nil
+			}
+		}
+		return "darwin", machoArch(ff.Arches[0].Cpu), nil
+	case bytes.Equal(magic, []byte{0xcf, 0xfa, 0xed, 0xfe}), bytes.Equal(magic, []byte{0xce, 0xfa, 0xed, 0xfe}):
+		mf, err := macho.NewFile(f)
+		if err != nil {
+			return "", "", fmt.Errorf("corrupt Mach-O binary: %w", err)
+		}
+		return "darwin", machoArch(mf.Cpu), nil
+	}
+	return "", "", nil
+}
+
+func machoArch(cpu macho.Cpu) string {
+	return map[macho.Cpu]string{
+		macho.CpuAmd64: "amd64",
+		macho.CpuArm64: "arm64",
+		macho.Cpu386:   "386",
+	}[cpu]
+}
+
+// confKeys are the conf values used by commands, with what happens
+// without them.
+var confKeys = []struct {
+	Query, Missing string
+}{
+	{".ipinfo", "ds scripts ipinfo returns less information without an ipinfo.io token"},
+}
+
+func checkConf() []Result {
+	if Z.Conf == nil {
+		return []Result{{Status: Warn, Message: "configuration is not available"}}
+	}
+	var results []Result
+	for _, k := range confKeys {
+		v, err := Z.Conf.Query(k.Query)
+		v = strings.TrimSpace(v)
+		if err != nil || v == "" || v == "null" {
+			results = append(results, Result{
+				Status:  Warn,
+				Message: fmt.Sprintf("conf %s is not set: %s", k.Query, k.Missing),
+				Fix:     "add it with ds conf edit",
+			})
+			continue
+		}
+		results = append(results, Result{Status: Pass, Message: fmt.Sprintf("conf %s is set", k.Query)})
+	}
+	if v := web.CABundle(); v != "" {
+		if _, err := os.Stat(v); err != nil {
+			results = append(results, Result{Status: Fail, Message: "conf " + web.CABundleQuery + ": " + err.Error(), Fix: "point cabundle at a PEM file with ds conf edit"})
+		} else {
+			results = append(results, Result{Status: Pass, Message: "conf " + web.CABundleQuery + " " + v + " exists"})
+		}
+	}
+	return results
+}
+
+func checkGithub() []Result {
+	var results []Result
+	token := get.GithubToken() != ""
+	if token {
+		results = append(results, Result{Status: Pass, Message: "a GitHub token is configured"})
+	} else {
+		results = append(results, Result{
+			Status:  Warn,
+			Message: "no GitHub token is configured, the API allows 60 requests an hour",
+			Fix:     "set GITHUB_TOKEN or add github.token with ds conf edit",
+		})
+	}
+
+	rl, err := get.GithubRateLimit()
+	if err != nil {
+		return append(results, Result{
+			Status:  Warn,
+			Message: "could not check the GitHub API rate limit: " + err.Error(),
+			Fix:     "check your network connection and proxy settings",
+		})
+	}
+	msg := fmt.Sprintf("GitHub API rate limit: %d of %d requests left, resets at %s",
+		rl.Remaining, rl.Limit, rl.Reset.Local().Format(time.Kitchen))
+	switch {
+	case rl.Remaining == 0:
+		results = append(results, Result{Status: Fail, Message: msg, Fix: "wait for the reset or configure a GitHub token"})
+	case rl.Remaining < rl.Limit/10:
+		results = append(results, Result{Status: Warn, Message: msg, Fix: "configure a GitHub token for a higher limit"})
+	default:
+		results = append(results, Result{Status: Pass, Message: msg})
+	}
+	return results
+}
+
+func checkDependencies() []Result {
+	var names []string
+	for name := range scripts.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var results []Result
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			results = append(results, Result{Status: Pass, Message: name + " found at " + path})
+			continue
+		}
+		results = append(results, Result{
+			Status:  Warn,
+			Message: fmt.Sprintf("%s not found, needed by ds scripts %s", name, strings.Join(scripts.Dependencies[name], ", ")),
+			Fix:     "install " + name + " with your package manager",
+		})
+	}
+	return results
+}
@@ -0,0 +1,62 @@
+package doctor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestBinaryPlatform(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	goos, goarch, err := binaryPlatform(exe)
+	if err != nil || goos != runtime.GOOS || goarch != runtime.GOARCH {
+		t.Errorf("binaryPlatform(test binary) = %s/%s, %v", goos, goarch, err)
+	}
+
+	script := filepath.Join(t.TempDir(), "script")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho hi\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	goos, goarch, err = binaryPlatform(script)
+	if err != nil || goos != "" || goarch != "" {
+		t.Errorf("binaryPlatform(script) = %q/%q, %v", goos, goarch, err)
+	}
+
+	corrupt := filepath.Join(t.TempDir(), "corrupt")
+	if err := os.WriteFile(corrupt, []byte("\x7fELF truncated"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := binaryPlatform(corrupt); err == nil {
+		t.Error("expected an error for a corrupt binary")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	failed := Print(&buf, []Result{
+		{Status: Pass, Message: "ok", Fix: "not shown"},
+		{Status: Warn, Message: "careful", Fix: "do this"},
+		{Status: Fail, Message: "broken", Fix: "do that"},
+	})
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	out := buf.String()
+	for _, want := range []string{"PASS  ok\n", "WARN  careful\n      fix: do this\n", "FAIL  broken\n      fix: do that\n", "1 passed, 1 warnings, 1 failed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "not shown") {
+		t.Error("fix printed for a passing check")
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Error("coloured when stdout is not a terminal")
+	}
+}
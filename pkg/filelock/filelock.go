@@ -0,0 +1,118 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+// Package filelock provides advisory locks on files so concurrent runs of
+// ds do not download, install or record the same tool at the same time.
+// Locks are held by the process and released when it exits, however it
+// exits.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// errBusy is returned by tryLock when another process holds the lock.
+var errBusy = errors.New("lock is held by another process")
+
+// Lock is an exclusive lock on a file.
+type Lock struct {
+	f *os.File
+}
+
+// Acquire takes an exclusive lock on the file at path, creating it and its
+// directory if needed. When another process holds the lock Acquire logs
+// the pid of the holder and waits for it to be released.
+func Acquire(path string) (*Lock, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	err = tryLock(f)
+	if errors.Is(err, errBusy) {
+		log.Printf("%s: waiting for lock held by pid %s\n", path, holder(f))
+		err = lock(f)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("locking %s: %w", path, err)
+	}
+	// The pid is only informational, failing to write it is not an error.
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &Lock{f: f}, nil
+}
+
+// Release releases the lock. The file is left in place as removing it
+// would race with processes waiting on it.
+func (l *Lock) Release() error {
+	if l == nil || l.f == nil {
+		return nil
+	}
+	err := unlock(l.f)
+	cerr := l.f.Close()
+	l.f = nil
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+// holder returns the pid written to the lock file by the process holding
+// it, or "unknown".
+func holder(f *os.File) string {
+	buf := make([]byte, 32)
+	n, _ := f.ReadAt(buf, 0)
+	pid := strings.TrimSpace(string(buf[:n]))
+	if _, err := strconv.Atoi(pid); err != nil {
+		return "unknown"
+	}
+	return pid
+}
+
+// WriteFile replaces the file at path with data so that readers see either
+// the old or the new contents, never part of either. The data is written to
+// a temporary file in the same directory and renamed over path. An existing
+// file keeps its permissions and a symlink is followed so the file it
+// points to is replaced, not the link. Callers that read the file before
+// writing it should hold a Lock around both.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
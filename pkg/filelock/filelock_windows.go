@@ -0,0 +1,35 @@
+//go:build windows
+
+package filelock
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"os"
+)
+
+// lockOffset is the byte locked in the file. It is past the pid so the
+// pid can still be read by processes waiting for the lock.
+const lockOffset = 1 << 30
+
+func lockFile(f *os.File, flags uint32) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	return windows.LockFileEx(windows.Handle(f.Fd()), flags|windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, ol)
+}
+
+func tryLock(f *os.File) error {
+	err := lockFile(f, windows.LOCKFILE_FAIL_IMMEDIATELY)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return errBusy
+	}
+	return err
+}
+
+func lock(f *os.File) error {
+	return lockFile(f, 0)
+}
+
+func unlock(f *os.File) error {
+	ol := &windows.Overlapped{Offset: lockOffset}
+	return windows.UnlockFileEx(windows.Handle(f.Fd()), 0, 1, 0, ol)
+}
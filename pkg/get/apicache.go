@@ -0,0 +1,152 @@
+package get
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/web"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// releasesTTL is how long cached release metadata is used without asking
+// GitHub at all, set with the get.releases_ttl conf value. Once it has
+// passed the cache is revalidated, which costs nothing against the rate
+// limit when the releases have not changed.
+var releasesTTL = 10 * time.Minute
+
+// maxAPIResponseSize limits the size of a cached API response.
+const maxAPIResponseSize = 32 << 20
+
+// apiResponse is a GitHub API response cached on disk with the validators
+// used to revalidate it.
+type apiResponse struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	Fetched      time.Time       `json:"fetched"`
+	Body         json.RawMessage `json:"body"`
+}
+
+// apiCacheFile returns where the response for url is cached.
+func apiCacheFile(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(CacheDir(), "api", hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadAPIResponse returns the cached response for url or nil when there is
+// none or it cannot be read.
+func loadAPIResponse(url string) *apiResponse {
+	data, err := os.ReadFile(apiCacheFile(url))
+	if err != nil {
+		return nil
+	}
+	var r apiResponse
+	if json.Unmarshal(data, &r) != nil || r.URL != url {
+		return nil
+	}
+	return &r
+}
+
+// save writes the response to the cache, replacing the old one in a single
+// rename so concurrent runs never read half of it.
+func (r *apiResponse) save() error {
+	file := apiCacheFile(r.URL)
+	err := mkdirp(filepath.Dir(file))
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(file, data, 0600)
+}
+
+// loadReleasesTTL returns releasesTTL or the get.releases_ttl conf value.
+func loadReleasesTTL() time.Duration {
+	s := confString(".get.releases_ttl")
+	if s == "" {
+		return releasesTTL
+	}
+	d, err := parseAge(s)
+	if err != nil {
+		log.Printf("Ignoring conf get.releases_ttl: %s\n", err)
+		return releasesTTL
+	}
+	return d
+}
+
+// githubGetCached returns the body of a GitHub API url, from the cache when
+// it was fetched within the TTL. Older cached responses are revalidated
+// with If-None-Match and If-Modified-Since, and used as they are when
+// GitHub cannot be reached or refuses the request, such as when the rate
+// limit has been used up.
+func githubGetCached(url string) ([]byte, error) {
+	cached := loadAPIResponse(url)
+	if cached != nil && time.Since(cached.Fetched) < loadReleasesTTL() {
+		return cached.Body, nil
+	}
+
+	req, err := newGithubRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	res, err := web.Client().Do(req)
+	if err == nil {
+		defer res.Body.Close()
+		if res.StatusCode == http.StatusNotModified && cached != nil {
+			cached.Fetched = time.Now().UTC()
+			if err := cached.save(); err != nil {
+				log.Printf("Could not update the API cache: %s\n", err)
+			}
+			return cached.Body, nil
+		}
+		if res.StatusCode != http.StatusOK {
+			err = fmt.Errorf("unexpected status code: %d", res.StatusCode)
+		}
+	}
+	if err != nil {
+		if cached != nil {
+			log.Printf("Using the response cached %s: %s\n", cached.Fetched.Local().Format(time.RFC822), err)
+			return cached.Body, nil
+		}
+		return nil, err
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxAPIResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxAPIResponseSize {
+		return nil, fmt.Errorf("response from %s is larger than %d bytes", url, maxAPIResponseSize)
+	}
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("invalid JSON response from %s", url)
+	}
+	r := &apiResponse{
+		URL:          url,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+		Fetched:      time.Now().UTC(),
+		Body:         body,
+	}
+	if err := r.save(); err != nil {
+		log.Printf("Could not update the API cache: %s\n", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,80 @@
+package get
+
+import (
+	"bytes"
+	"github.com/danielmichaels/ds/pkg/web"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGithubGetCached(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	requests, notModified := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, `[{"tag_name":"v1.0.0"}]`)
+	}))
+	url := srv.URL + "/repos/example/tool/releases"
+
+	defer func(ttl time.Duration) { releasesTTL = ttl }(releasesTTL)
+
+	tt := []struct {
+		name        string
+		ttl         time.Duration
+		requests    int
+		notModified int
+	}{
+		{"first", time.Hour, 1, 0},
+		{"within ttl", time.Hour, 1, 0},
+		{"revalidated", 0, 2, 1},
+	}
+	for _, tc := range tt {
+		releasesTTL = tc.ttl
+		body, err := githubGetCached(url)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.name, err)
+		}
+		if string(body) != `[{"tag_name":"v1.0.0"}]` {
+			t.Errorf("%s: got body %q", tc.name, body)
+		}
+		if requests != tc.requests || notModified != tc.notModified {
+			t.Errorf("%s: %d requests, %d not modified, want %d and %d", tc.name, requests, notModified, tc.requests, tc.notModified)
+		}
+	}
+
+	// The cached response is used when GitHub cannot be reached.
+	srv.Close()
+	defer func(n int) { web.Retries = n }(web.Retries)
+	web.Retries = 0
+	body, err := githubGetCached(url)
+	if err != nil || string(body) != `[{"tag_name":"v1.0.0"}]` {
+		t.Errorf("offline: got %q, %v", body, err)
+	}
+	_, err = githubGetCached(srv.URL + "/repos/example/other/releases")
+	if err == nil {
+		t.Error("offline without a cached response: no error")
+	}
+}
+
+func TestGithubGetCachedTooLarge(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte(" "), maxAPIResponseSize+1))
+	}))
+	defer srv.Close()
+
+	_, err := githubGetCached(srv.URL + "/repos/example/huge/releases")
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("got %v, want a size limit error", err)
+	}
+}
@@ -25,6 +25,8 @@ func decompressArchive(tool *Tool, dlURL, outFilePath, opSystem, arch, version s
 	outFilePath = path.Join(target, tool.Name)
 
 	switch {
+	case packageFormat(dlURL) != "":
+		return extractPackage(file, packageFormat(dlURL), tool.Name, target)
 	case strings.HasSuffix(dlURL, "tar.gz"):
 		err := Untar(file, target, true)
 		if err != nil {
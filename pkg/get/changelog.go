@@ -0,0 +1,239 @@
+package get
+
+import (
+	"fmt"
+	"github.com/charmbracelet/glamour"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// changelogWordWrap is the width release notes are wrapped to.
+const changelogWordWrap = 100
+
+// releaseNote is a release shown by the changelog command.
+type releaseNote struct {
+	Tag       string    `json:"tag" yaml:"tag"`
+	Name      string    `json:"name" yaml:"name"`
+	Published time.Time `json:"published" yaml:"published"`
+	URL       string    `json:"url" yaml:"url"`
+	Body      string    `json:"body" yaml:"body"`
+}
+
+// releasesBetween returns the releases after from up to and including to,
+// newest first. Drafts are skipped and so are prereleases unless pre is
+// set or to is one. Releases are compared as versions when from and to
+// can be parsed as versions, otherwise by their order on GitHub.
+func releasesBetween(releases []*GithubAPIReleasesResponse, from, to string, pre bool) []*GithubAPIReleasesResponse {
+	fv, fok := parseSemver(from)
+	tv, tok := parseSemver(to)
+	pre = pre || (tok && tv.Pre != "")
+
+	var list []*GithubAPIReleasesResponse
+	if fok && tok {
+		for _, r := range releases {
+			v, ok := parseSemver(r.TagName)
+			if r.Draft || !ok || ((r.Prerelease || v.Pre != "") && !pre) {
+				continue
+			}
+			if v.compare(fv) > 0 && v.compare(tv) <= 0 {
+				list = append(list, r)
+			}
+		}
+		sort.SliceStable(list, func(i, j int) bool {
+			a, _ := parseSemver(list[i].TagName)
+			b, _ := parseSemver(list[j].TagName)
+			return a.compare(b) > 0
+		})
+		return list
+	}
+
+	// GitHub lists releases newest first.
+	found := false
+	for _, r := range releases {
+		if r.TagName == from || r.Name == from {
+			break
+		}
+		if r.TagName == to || r.Name == to {
+			found = true
+		}
+		if found && !r.Draft && (!r.Prerelease || pre) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// releaseNotes converts releases to the notes shown to people.
+func releaseNotes(releases []*GithubAPIReleasesResponse) []releaseNote {
+	notes := []releaseNote{}
+	for _, r := range releases {
+		notes = append(notes, releaseNote{
+			Tag:       r.TagName,
+			Name:      r.Name,
+			Published: r.PublishedAt,
+			URL:       r.HtmlUrl,
+			Body:      strings.TrimSpace(strings.ReplaceAll(r.Body, "\r\n", "\n")),
+		})
+	}
+	return notes
+}
+
+// changelogMarkdown joins the notes into one markdown document with a
+// heading for each release.
+func changelogMarkdown(name string, notes []releaseNote) string {
+	var b strings.Builder
+	for _, n := range notes {
+		title := n.Tag
+		if n.Name != "" && n.Name != n.Tag {
+			title += " " + n.Name
+		}
+		fmt.Fprintf(&b, "# %s %s\n\n", name, title)
+		if !n.Published.IsZero() {
+			fmt.Fprintf(&b, "*Released %s* %s\n\n", n.Published.Local().Format("2 Jan 2006"), n.URL)
+		}
+		if n.Body == "" {
+			b.WriteString("No release notes.\n\n")
+			continue
+		}
+		b.WriteString(n.Body + "\n\n")
+	}
+	return b.String()
+}
+
+// writeMarkdown renders markdown to w when colour is in use and writes it
+// as it is otherwise, so it can be piped.
+func writeMarkdown(w io.Writer, md string) error {
+	if !UseColor() {
+		_, err := io.WriteString(w, md)
+		return err
+	}
+	r, err := glamour.NewTermRenderer(
+		glamour.WithAutoStyle(), glamour.WithWordWrap(changelogWordWrap),
+	)
+	if err != nil {
+		return err
+	}
+	out, err := r.Render(md)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+// printUpgradeNotes shows the release notes between the installed version
+// of the tool and version, when it is an upgrade. Failing to fetch them
+// does not stop the upgrade.
+func printUpgradeNotes(tool *Tool, version string) {
+	st, err := LoadState()
+	if err != nil {
+		return
+	}
+	in, ok := st.Tools[tool.Name]
+	if !ok {
+		return
+	}
+	target, err := resolveRelease(tool, version)
+	if err != nil || !newer(in.Version, target.TagName) {
+		return
+	}
+	releases, err := FindGithubRelease(tool.Owner, tool.Repo)
+	if err != nil {
+		return
+	}
+	list := releasesBetween(releases, in.Version, target.TagName, tool.Channel == ChannelPre)
+	if len(list) == 0 {
+		return
+	}
+	log.Printf("Upgrading %s from %s to %s\n", tool.Name, in.Version, target.TagName)
+	err = writeMarkdown(os.Stdout, changelogMarkdown(tool.Name, releaseNotes(list)))
+	if err != nil {
+		log.Printf("Could not show the release notes: %s\n", err)
+	}
+}
+
+var changelog = &Z.Cmd{
+	Name:     `changelog`,
+	Summary:  `show the release notes between two versions of a tool [requires internet]`,
+	Usage:    `[--pre] [--output FORMAT] TOOL [FROM] [TO]`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *changelog* command shows the release notes of every release of
+		a tool after FROM up to and including TO, newest first, so breaking
+		changes can be read before upgrading. FROM defaults to the installed
+		version and TO to the version *ds get* would install. The notes are
+		rendered as markdown in a terminal and written as they are
+		otherwise.
+
+		The same notes are shown when *ds get* upgrades an installed tool.
+
+		    ds get changelog k9s
+		    ds get changelog argocd v2.4.0 v2.5.0`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--pre", "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 || len(args) > 3 {
+			return caller.UsageError()
+		}
+		t, err := getTool(args[0], Registry())
+		if err != nil {
+			return err
+		}
+		if opts.Pre {
+			t.Channel = ChannelPre
+		}
+
+		var from string
+		if len(args) > 1 {
+			from = args[1]
+		} else {
+			st, err := LoadState()
+			if err != nil {
+				return err
+			}
+			in, ok := st.Tools[t.Name]
+			if !ok {
+				return fmt.Errorf("%s is not installed, give the version to start from", t.Name)
+			}
+			from = in.Version
+		}
+		to := t.Version
+		if len(args) > 2 {
+			to = args[2]
+		}
+		if to == "" {
+			to = "latest"
+		}
+		target, err := resolveRelease(&t, to)
+		if err != nil {
+			return err
+		}
+		releases, err := FindGithubRelease(t.Owner, t.Repo)
+		if err != nil {
+			return err
+		}
+		notes := releaseNotes(releasesBetween(releases, from, target.TagName, t.Channel == ChannelPre))
+
+		if opts.Output != OutputTable {
+			v := view{Header: []string{"Tag", "Name", "Published", "URL"}, Value: notes}
+			for _, n := range notes {
+				v.Rows = append(v.Rows, []string{n.Tag, n.Name, n.Published.Format(time.RFC3339), n.URL})
+			}
+			return v.write(os.Stdout, opts.Output)
+		}
+		if len(notes) == 0 {
+			log.Printf("No releases of %s after %s up to %s\n", t.Name, from, target.TagName)
+			return nil
+		}
+		return writeMarkdown(os.Stdout, changelogMarkdown(t.Name, notes))
+	},
+}
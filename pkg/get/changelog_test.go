@@ -0,0 +1,51 @@
+package get
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReleasesBetween(t *testing.T) {
+	releases := []*GithubAPIReleasesResponse{
+		{TagName: "v0.28.0-rc.1", Prerelease: true},
+		{TagName: "v0.27.10"},
+		{TagName: "v0.27.9", Draft: true},
+		{TagName: "v0.27.4"},
+		{TagName: "v0.27.3"},
+		{TagName: "v0.26.0"},
+		{TagName: "nightly"},
+	}
+	tt := []struct {
+		from, to string
+		pre      bool
+		want     string
+	}{
+		{"v0.26.0", "v0.27.10", false, "v0.27.10 v0.27.4 v0.27.3"},
+		{"v0.27.3", "v0.27.4", false, "v0.27.4"},
+		{"v0.27.4", "v0.28.0-rc.1", false, "v0.28.0-rc.1 v0.27.10"},
+		{"v0.27.10", "v0.27.10", false, ""},
+		{"nightly", "v0.27.4", false, "v0.27.4 v0.27.3 v0.26.0"},
+	}
+	for _, tc := range tt {
+		var got []string
+		for _, r := range releasesBetween(releases, tc.from, tc.to, tc.pre) {
+			got = append(got, r.TagName)
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("releasesBetween(%s, %s) = %v, want %s", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestChangelogMarkdown(t *testing.T) {
+	notes := releaseNotes([]*GithubAPIReleasesResponse{
+		{TagName: "v0.27.4", Name: "Fixes", Body: "* fix crash\r\n"},
+		{TagName: "v0.27.3", Name: "v0.27.3"},
+	})
+	md := changelogMarkdown("k9s", notes)
+	for _, want := range []string{"# k9s v0.27.4 Fixes\n\n* fix crash\n", "# k9s v0.27.3\n\nNo release notes."} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown %q does not contain %q", md, want)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package get
+
+import (
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultMaxAge is how long a cached version may go unused before clean
+// removes it when get.clean.max_age is not set.
+const defaultMaxAge = 30 * 24 * time.Hour
+
+// Budget limits the size of the run cache. A zero value is no limit.
+type Budget struct {
+	MaxAge  time.Duration
+	MaxSize int64
+}
+
+// parseAge parses a duration such as "12h" or "30d".
+func parseAge(s string) (time.Duration, error) {
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n * float64(24*time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// parseSize parses a size in bytes with an optional K, M, G or T suffix,
+// such as "500M" or "2GB". Units are powers of 1024.
+func parseSize(s string) (int64, error) {
+	num := strings.ToUpper(strings.TrimSpace(s))
+	num = strings.TrimSuffix(strings.TrimSuffix(num, "B"), "I")
+	mult := int64(1)
+	for i, u := range sizeUnits[1:] {
+		if strings.HasSuffix(num, u[:1]) {
+			num = strings.TrimSuffix(num, u[:1])
+			mult = int64(1) << (10 * (i + 1))
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(mult)), nil
+}
+
+// formatSize formats n bytes for people.
+func formatSize(n int64) string {
+	f := float64(n)
+	i := 0
+	for f >= 1024 && i < len(sizeUnits)-1 {
+		f /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", f, sizeUnits[i])
+}
+
+// confString returns a conf value or an empty string if it is not set.
+func confString(query string) string {
+	if Z.Conf == nil {
+		return ""
+	}
+	v, err := Z.Conf.Query(query)
+	if err != nil {
+		return ""
+	}
+	v = strings.TrimSpace(v)
+	if v == "null" {
+		return ""
+	}
+	return v
+}
+
+// loadBudget returns the budget from the get.clean.max_age and
+// get.clean.max_size conf values, overridden by the command line.
+func loadBudget(opts options) (Budget, error) {
+	b := Budget{MaxAge: defaultMaxAge}
+	age := confString(".get.clean.max_age")
+	if opts.MaxAge != "" {
+		age = opts.MaxAge
+	}
+	if age != "" {
+		d, err := parseAge(age)
+		if err != nil {
+			return b, err
+		}
+		b.MaxAge = d
+	}
+	size := confString(".get.clean.max_size")
+	if opts.MaxSize != "" {
+		size = opts.MaxSize
+	}
+	if size != "" {
+		n, err := parseSize(size)
+		if err != nil {
+			return b, err
+		}
+		b.MaxSize = n
+	}
+	return b, nil
+}
+
+// cacheEntry is one cached version of a tool used by ds run.
+type cacheEntry struct {
+	Tool    string    `json:"tool" yaml:"tool"`
+	Version string    `json:"version" yaml:"version"`
+	Path    string    `json:"path" yaml:"path"`
+	Size    int64     `json:"size" yaml:"size"`
+	Used    time.Time `json:"used" yaml:"used"`
+}
+
+// dirSize returns the total size of the files under path.
+func dirSize(path string) int64 {
+	var size int64
+	_ = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if fi, err := d.Info(); err == nil {
+				size += fi.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
+
+// runCacheEntries returns every version in the run cache. A version was
+// last used when its directory was last modified, see RunBinary.
+func runCacheEntries() []cacheEntry {
+	tools, err := os.ReadDir(filepath.Join(CacheDir(), "run"))
+	if err != nil {
+		return nil
+	}
+	var entries []cacheEntry
+	for _, t := range tools {
+		if !t.IsDir() {
+			continue
+		}
+		for _, v := range cachedVersions(t.Name()) {
+			path := filepath.Join(runCacheDir(t.Name()), v)
+			fi, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			entries = append(entries, cacheEntry{
+				Tool:    t.Name(),
+				Version: v,
+				Path:    path,
+				Size:    dirSize(path),
+				Used:    fi.ModTime(),
+			})
+		}
+	}
+	return entries
+}
+
+// prune returns the entries outside the budget: those not used within
+// MaxAge and then the least recently used until the rest fit in MaxSize.
+func prune(entries []cacheEntry, b Budget, now time.Time) []cacheEntry {
+	sorted := make([]cacheEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Used.Before(sorted[j].Used) })
+
+	var total int64
+	for _, e := range sorted {
+		total += e.Size
+	}
+	var list []cacheEntry
+	for _, e := range sorted {
+		expired := b.MaxAge > 0 && now.Sub(e.Used) > b.MaxAge
+		over := b.MaxSize > 0 && total > b.MaxSize
+		if !expired && !over {
+			continue
+		}
+		list = append(list, e)
+		total -= e.Size
+	}
+	return list
+}
+
+// usage is the disk space used by one area ds writes to.
+type usage struct {
+	Area      string `json:"area" yaml:"area"`
+	Path      string `json:"path" yaml:"path"`
+	Size      int64  `json:"size" yaml:"size"`
+	Reclaimed int64  `json:"reclaimed" yaml:"reclaimed"`
+}
+
+var clean = &Z.Cmd{
+	Name:     `clean`,
+	Summary:  `remove temporary files and prune the run cache`,
+	Usage:    `[--dry-run] [--max-age AGE] [--max-size SIZE] [--output FORMAT]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *clean* command removes temporary directories left behind by
+		runs of ds that were killed and prunes the versions cached by *ds
+		run*. A cached version is removed when it has not been used within
+		the maximum age, 30 days by default, and then the least recently
+		used are removed until the cache fits in the maximum size, which is
+		unlimited by default. Installed tools and tool indexes are never
+		removed. The disk space used by ds is reported afterwards.
+
+		The budget is set with the *get.clean.max_age* and
+		*get.clean.max_size* conf values or the flags. Ages accept a *d*
+		suffix for days as well as hours, minutes and seconds, and sizes a
+		K, M, G or T suffix.
+
+		    ds get clean --dry-run
+		    ds get clean --max-age 7d --max-size 500M`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--dry-run", "--max-age", "--max-size", "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		b, err := loadBudget(opts)
+		if err != nil {
+			return err
+		}
+		verb := "Removed"
+		if opts.DryRun {
+			verb = "Would remove"
+		}
+		remove := func(path string) {
+			if opts.DryRun {
+				return
+			}
+			err := os.RemoveAll(path)
+			if err != nil {
+				log.Printf("Could not remove %s: %s\n", path, err)
+			}
+		}
+
+		var tmpSize, tmpReclaimed int64
+		for _, d := range tempdir.Stale() {
+			size := dirSize(d)
+			tmpSize += size
+			tmpReclaimed += size
+			log.Printf("%s %s (%s)\n", verb, d, formatSize(size))
+			remove(d)
+		}
+
+		entries := runCacheEntries()
+		var runSize, runReclaimed int64
+		for _, e := range entries {
+			runSize += e.Size
+		}
+		for _, e := range prune(entries, b, time.Now()) {
+			runReclaimed += e.Size
+			log.Printf("%s %s %s (%s, last used %s)\n", verb, e.Tool, e.Version, formatSize(e.Size), e.Used.Local().Format(time.RFC822))
+			if opts.DryRun {
+				continue
+			}
+			l, err := filelock.Acquire(runCacheDir(e.Tool) + ".lock")
+			if err != nil {
+				return err
+			}
+			remove(e.Path)
+			if len(cachedVersions(e.Tool)) == 0 {
+				remove(runCacheDir(e.Tool))
+			}
+			l.Release()
+		}
+
+		indexDir := filepath.Join(CacheDir(), "index")
+		apiDir := filepath.Join(CacheDir(), "api")
+		list := []usage{
+			{Area: "bin", Path: BinDir(), Size: dirSize(BinDir())},
+			{Area: "run cache", Path: filepath.Join(CacheDir(), "run"), Size: runSize, Reclaimed: runReclaimed},
+			{Area: "index cache", Path: indexDir, Size: dirSize(indexDir)},
+			{Area: "api cache", Path: apiDir, Size: dirSize(apiDir)},
+			{Area: "temp", Path: os.TempDir(), Size: tmpSize, Reclaimed: tmpReclaimed},
+		}
+		v := view{Header: []string{"Area", "Path", "Size", "Reclaimed"}, Value: list}
+		var total, reclaimed int64
+		for _, u := range list {
+			total += u.Size
+			reclaimed += u.Reclaimed
+			v.Rows = append(v.Rows, []string{u.Area, u.Path, formatSize(u.Size), formatSize(u.Reclaimed)})
+		}
+		v.Caption = fmt.Sprintf("%s used, %s reclaimed.\n", formatSize(total-reclaimed), formatSize(reclaimed))
+		if opts.DryRun {
+			v.Caption = fmt.Sprintf("%s used, %s would be reclaimed.\n", formatSize(total), formatSize(reclaimed))
+		}
+		return v.write(os.Stdout, opts.Output)
+	},
+}
@@ -0,0 +1,88 @@
+package get
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAge(t *testing.T) {
+	tt := []struct {
+		in   string
+		want time.Duration
+		err  bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"90m", 90 * time.Minute, false},
+		{"week", 0, true},
+		{"-1d", 0, true},
+	}
+	for _, tc := range tt {
+		got, err := parseAge(tc.in)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("parseAge(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tt := []struct {
+		in   string
+		want int64
+		err  bool
+	}{
+		{"1024", 1024, false},
+		{"500M", 500 << 20, false},
+		{"500MB", 500 << 20, false},
+		{"2GiB", 2 << 30, false},
+		{"1.5k", 1536, false},
+		{"lots", 0, true},
+	}
+	for _, tc := range tt {
+		got, err := parseSize(tc.in)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("parseSize(%q) = %v, %v, want %v", tc.in, got, err, tc.want)
+		}
+	}
+	if got := formatSize(1536); got != "1.5 KB" {
+		t.Errorf("formatSize(1536) = %q", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	day := 24 * time.Hour
+	entries := []cacheEntry{
+		{Tool: "hey", Version: "v0.1.4", Size: 10, Used: now.Add(-1 * day)},
+		{Tool: "hey", Version: "v0.1.3", Size: 10, Used: now.Add(-40 * day)},
+		{Tool: "k9s", Version: "v0.26.0", Size: 30, Used: now.Add(-2 * day)},
+		{Tool: "popeye", Version: "v0.10.0", Size: 20, Used: now.Add(-3 * day)},
+	}
+	tt := []struct {
+		name   string
+		budget Budget
+		want   []string
+	}{
+		{"none", Budget{}, nil},
+		{"age", Budget{MaxAge: 30 * day}, []string{"v0.1.3"}},
+		{"size", Budget{MaxSize: 40}, []string{"v0.1.3", "v0.10.0"}},
+		{"both", Budget{MaxAge: 30 * day, MaxSize: 10}, []string{"v0.1.3", "v0.10.0", "v0.26.0"}},
+	}
+	for _, tc := range tt {
+		var got []string
+		for _, e := range prune(entries, tc.budget, now) {
+			got = append(got, e.Version)
+		}
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: prune = %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: prune = %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
@@ -3,20 +3,45 @@ package get
 import (
 	"bytes"
 	"fmt"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	"github.com/danielmichaels/ds/pkg/web"
 	"github.com/schollz/progressbar/v3"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 	"text/template"
+	"time"
 )
 
 var (
 	//toolFilePath = ".local/bin"
 	toolFilePath = ".ds/bin"
+	manFilePath  = ".ds/share/man"
 )
 
+// installRoot is the directory holding the .ds tree tools are installed
+// into. It is $HOME unless UseProject has been called.
+var installRoot string
+
+// UseProject installs tools into dir/.ds/bin and records them in
+// dir/.ds/state.json rather than under $HOME. Manual pages and completions
+// are still installed into the home directory.
+func UseProject(dir string) {
+	installRoot = dir
+}
+
+// InstallRoot returns the directory holding the .ds tree tools are
+// installed into.
+func InstallRoot() string {
+	if installRoot != "" {
+		return installRoot
+	}
+	return os.Getenv("HOME")
+}
+
 func mkdirp(path string) error {
 	err := os.MkdirAll(path, 0700)
 	if err != nil {
@@ -27,7 +52,7 @@ func mkdirp(path string) error {
 
 // InitUserDir will establish a location for the binaries to be stored.
 func InitUserDir() (string, error) {
-	home := os.Getenv("HOME")
+	home := InstallRoot()
 	binPath := fmt.Sprintf("%s/%s", home, toolFilePath)
 	err := mkdirp(binPath)
 	if err != nil {
@@ -39,9 +64,30 @@ func InitUserDir() (string, error) {
 	return binPath, nil
 }
 
-// LocalBinary returns the filepath for the binary in the users home directory.
+// BinDir returns the directory tools are installed into.
+func BinDir() string {
+	return filepath.Join(InstallRoot(), toolFilePath)
+}
+
+// ManDir returns the directory manual pages for tools are installed into.
+func ManDir() string {
+	return filepath.Join(os.Getenv("HOME"), manFilePath)
+}
+
+// OnPath reports whether dir is one of the directories in $PATH.
+func OnPath(dir string) bool {
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == filepath.Clean(dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalBinary returns the filepath for the binary in the users home directory,
+// or the project directory when UseProject has been called.
 func LocalBinary(name, subdir string) (string, error) {
-	home := os.Getenv("HOME")
+	home := InstallRoot()
 
 	val := path.Join(home, toolFilePath)
 	if len(subdir) > 0 {
@@ -52,46 +98,115 @@ func LocalBinary(name, subdir string) (string, error) {
 }
 
 // Download is a public interface for downloading a file from a provided URL.
-func Download(tool *Tool, arch, opSystem, version string) (string, error) {
-	dlURL, err := GetDownloadURL(*tool, arch, opSystem, version)
+// The installed tool is recorded in the state file and returned.
+func Download(tool *Tool, arch, opSystem, version string) (*Installed, error) {
+	asset, err := ResolveAsset(*tool, arch, opSystem, version)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	log.Printf("Downloading %q", dlURL)
+	return installAsset(tool, asset, "")
+}
 
-	outputPath, err := downloadFile(dlURL)
+// installAsset downloads asset and installs the tool from it. When sum is
+// not empty the download must have that SHA-256 sum, as recorded in a lock
+// file, otherwise it is verified against the tool's Verify assets.
+func installAsset(tool *Tool, asset *Asset, sum string) (*Installed, error) {
+	l, err := lockTool(tool.Name)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
+	defer l.Release()
 
-	if isArchive, err := tool.IsArchive(dlURL); isArchive {
-		if err != nil {
-			return "", err
-		}
-
-		out, err := decompressArchive(tool, dlURL, outputPath, opSystem, arch, version)
-		if err != nil {
-			return "", err
-		}
-		outputPath = out
-		log.Printf("Extracted %q\n", outputPath)
+	outputPath, err := fetchBinary(tool, asset, sum)
+	if err != nil {
+		return nil, err
 	}
 
 	_, err = InitUserDir()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	localPath, err := LocalBinary(tool.Name, "")
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	_, err = CopyFile(outputPath, localPath, 0700)
 	log.Printf("Copied %q to %q\n", outputPath, localPath)
+	if err != nil {
+		return nil, err
+	}
+
+	binSum, err := sha256File(localPath)
+	if err != nil {
+		return nil, err
+	}
+	res := &Installed{
+		Name:        tool.Name,
+		Version:     asset.Version,
+		Path:        localPath,
+		URL:         asset.URL,
+		SHA256:      binSum,
+		InstalledAt: time.Now().UTC(),
+	}
+	err = recordInstall(*res)
+	if err != nil {
+		return nil, err
+	}
+
+	err = RunPostInstall(tool, localPath, asset.Version)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// fetchBinary downloads and verifies asset, as described for installAsset,
+// and returns the path of the tool's binary, extracted from the asset if it
+// is an archive.
+func fetchBinary(tool *Tool, asset *Asset, sum string) (string, error) {
+	dlURL := asset.URL
+	log.Printf("Downloading %q", dlURL)
+
+	outputPath, err := downloadFile(dlURL)
 	if err != nil {
 		return "", err
 	}
+
+	if sum != "" {
+		got, err := sha256File(outputPath)
+		if err != nil {
+			return "", err
+		}
+		if !strings.EqualFold(got, sum) {
+			return "", fmt.Errorf("%s: %q does not match the lock file: got sha256 %s, want %s", tool.Name, asset.Name, got, sum)
+		}
+	} else {
+		err = VerifyAsset(tool, asset, outputPath)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if isArchive, err := tool.IsArchive(dlURL); isArchive {
+		if err != nil {
+			return "", err
+		}
+
+		out, err := decompressArchive(tool, dlURL, outputPath, asset.OS, asset.Arch, asset.Version)
+		if err != nil {
+			return "", err
+		}
+		outputPath = out
+		log.Printf("Extracted %q\n", outputPath)
+	}
+	if tool.NonBinary {
+		err = checkInterpreter(tool, outputPath)
+		if err != nil {
+			return "", err
+		}
+	}
 	return outputPath, nil
 }
 
@@ -99,15 +214,9 @@ func Download(tool *Tool, arch, opSystem, version string) (string, error) {
 // machine returning the path of that file.
 // A file length is required to render the download progress bar.
 func downloadFile(url string) (string, error) {
-	cl := httpClient(&httpTimeout)
-	r, err := http.NewRequest(http.MethodGet, url, nil)
+	res, err := web.Get(url)
 	if err != nil {
-		return "", nil
-	}
-
-	res, err := cl.Do(r)
-	if err != nil {
-		return "", nil
+		return "", err
 	}
 
 	if res.Body != nil {
@@ -119,8 +228,11 @@ func downloadFile(url string) (string, error) {
 	}
 
 	_, file := path.Split(url)
-	tmp := os.TempDir()
-	outFilePath := path.Join(tmp, file)
+	tmp, err := tempdir.MkdirTemp("download-")
+	if err != nil {
+		return "", err
+	}
+	outFilePath := filepath.Join(tmp, file)
 	out, err := os.Create(outFilePath)
 	if err != nil {
 		return "", err
@@ -166,6 +278,7 @@ type ToolLocal struct {
 	Name    string
 	Path    string
 	BinPath string
+	OnPath  bool
 }
 
 // PostInstallationMessage generates installation message after tool has been downloaded
@@ -173,20 +286,25 @@ func PostInstallationMessage(localToolsStore ToolLocal) ([]byte, error) {
 
 	t := template.New("Installation Instructions")
 
-	t.Parse(`
-# Add arkade binary directory to your PATH variable
-export PATH=$PATH:$HOME/{{.BinPath}}
-
+	_, err := t.Parse(`
 # Test the binary:
 {{.Path}}
+{{- if not .OnPath}}
+
+# The ds binary directory is not on your PATH. Add it for this shell with:
+eval "$(ds shellenv)"
 
-# Or install with:
-sudo mv {{.Path}} /usr/local/bin/
+# Or add it to your shell's rc file permanently with:
+ds shellenv --install
+{{- end}}
 `)
+	if err != nil {
+		return nil, err
+	}
 
 	var tpl bytes.Buffer
 
-	err := t.Execute(&tpl, localToolsStore)
+	err = t.Execute(&tpl, localToolsStore)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,424 @@
+package get
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Formats written by export.
+const (
+	ExportSh         = "sh"
+	ExportDockerfile = "dockerfile"
+	ExportAnsible    = "ansible"
+)
+
+var ExportFormats = []string{ExportSh, ExportDockerfile, ExportAnsible}
+
+// exportImage is the image the Dockerfile stage downloads tools in. Its
+// busybox provides wget, sha256sum, tar, unzip and install.
+var exportImage = "alpine:3.17"
+
+// Archive formats of exported assets. A raw asset is the binary itself.
+const (
+	exportRaw   = "raw"
+	exportTarGz = "tar.gz"
+	exportZip   = "zip"
+)
+
+// exportItem is a tool resolved to a single asset with everything needed
+// to install it without ds.
+type exportItem struct {
+	Name    string
+	Version string
+	URL     string
+	SHA256  string
+
+	// Asset is the file name of the asset.
+	Asset string
+
+	// Format is exportRaw, exportTarGz or exportZip.
+	Format string
+
+	// Member is the path of the binary in an archive as it is stored.
+	Member string
+}
+
+// exportFormat returns the archive format of an asset URL.
+func exportFormat(url string) (string, error) {
+	switch {
+	case packageFormat(url) != "":
+		return "", fmt.Errorf("%s packages cannot be exported", packageFormat(url))
+	case strings.HasSuffix(url, "tar.gz"), strings.HasSuffix(url, "tgz"):
+		return exportTarGz, nil
+	case strings.HasSuffix(url, "zip"):
+		return exportZip, nil
+	}
+	return exportRaw, nil
+}
+
+// archiveMember returns the stored path of the executable called name in
+// the archive file, preferring one in a bin directory.
+func archiveMember(file, format, name string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var member string
+	best := 0
+	consider := func(p string) {
+		if score := binaryScore(p, name); score > best {
+			member, best = p, score
+		}
+	}
+	switch format {
+	case exportTarGz:
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return "", err
+		}
+		tr := tar.NewReader(zr)
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", err
+			}
+			if h.Typeflag == tar.TypeReg || h.Typeflag == tar.TypeRegA {
+				consider(h.Name)
+			}
+		}
+	case exportZip:
+		fi, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		zr, err := zip.NewReader(f, fi.Size())
+		if err != nil {
+			return "", err
+		}
+		for _, zf := range zr.File {
+			if zf.Mode().IsRegular() {
+				consider(zf.Name)
+			}
+		}
+	}
+	if member == "" {
+		return "", fmt.Errorf("%s archive does not contain %s", format, name)
+	}
+	return member, nil
+}
+
+// exportTool resolves version of the tool to its asset for the platform.
+// The asset is downloaded and verified, like LockTool, to record its sum
+// and find the binary inside it.
+func exportTool(tool *Tool, version string, p Platform) (exportItem, error) {
+	item := exportItem{Name: tool.Name}
+	arch, opSystem := clientArch(p.OS, p.Arch)
+	asset, err := ResolveAsset(*tool, arch, opSystem, version)
+	if err != nil {
+		return item, err
+	}
+	item.Version = asset.Version
+	item.URL = asset.URL
+	item.Asset = path.Base(asset.URL)
+	item.Format, err = exportFormat(asset.URL)
+	if err != nil {
+		return item, fmt.Errorf("%s: %w", tool.Name, err)
+	}
+
+	file, err := downloadFile(asset.URL)
+	if err != nil {
+		return item, err
+	}
+	defer os.Remove(file)
+	err = VerifyAsset(tool, asset, file)
+	if err != nil {
+		return item, err
+	}
+	item.SHA256, err = sha256File(file)
+	if err != nil {
+		return item, err
+	}
+	if item.Format != exportRaw {
+		item.Member, err = archiveMember(file, item.Format, tool.Name)
+		if err != nil {
+			return item, fmt.Errorf("%s: %w", tool.Name, err)
+		}
+	}
+	for _, name := range []string{item.Asset, item.Member} {
+		if name != "" && !safeExportPath.MatchString(name) {
+			return item, fmt.Errorf("%s: cannot export the file name %q", tool.Name, name)
+		}
+	}
+	return item, nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// safeExportPath matches the asset and member names written into exported
+// scripts, which are double quoted.
+var safeExportPath = regexp.MustCompile(`^[A-Za-z0-9._+/-]+$`)
+
+// installSteps returns the shell commands installing the item from the
+// asset already downloaded to dir/Asset into bin. dir and bin may refer to
+// shell variables.
+func (item exportItem) installSteps(dir, bin string) []string {
+	file := fmt.Sprintf(`"%s/%s"`, dir, item.Asset)
+	extracted := fmt.Sprintf(`"%s/%s"`, dir, strings.TrimPrefix(path.Clean("/"+item.Member), "/"))
+	var steps []string
+	switch item.Format {
+	case exportTarGz:
+		steps = append(steps, fmt.Sprintf(`tar -xzf %s -C "%s" "%s"`, file, dir, item.Member))
+	case exportZip:
+		steps = append(steps, fmt.Sprintf(`unzip -q -o %s "%s" -d "%s"`, file, item.Member, dir))
+	default:
+		extracted = file
+	}
+	return append(steps, fmt.Sprintf(`install -m 0755 %s "%s/%s"`, extracted, bin, item.Name))
+}
+
+// exportHeader describes where exported output came from.
+func exportHeader(p Platform) string {
+	return fmt.Sprintf("Generated by ds get export for %s, every download is pinned to its SHA-256 sum.", p)
+}
+
+// exportSh writes a POSIX script installing the items into $BIN_DIR,
+// /usr/local/bin by default, with curl or wget.
+func exportSh(w io.Writer, items []exportItem, p Platform) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n# %s\n", exportHeader(p))
+	b.WriteString(`set -eu
+
+BIN_DIR="${BIN_DIR:-/usr/local/bin}"
+tmp="$(mktemp -d)"
+trap 'rm -rf "$tmp"' EXIT
+
+# fetch URL FILE SHA256 downloads URL to FILE and checks its sum.
+fetch() {
+	if command -v curl >/dev/null 2>&1; then
+		curl -fsSL -o "$2" "$1"
+	else
+		wget -q -O "$2" "$1"
+	fi
+	if command -v sha256sum >/dev/null 2>&1; then
+		sum="$(sha256sum "$2" | cut -d ' ' -f 1)"
+	else
+		sum="$(shasum -a 256 "$2" | cut -d ' ' -f 1)"
+	fi
+	if [ "$sum" != "$3" ]; then
+		echo "checksum mismatch for $1: got $sum, want $3" >&2
+		exit 1
+	fi
+}
+
+mkdir -p "$BIN_DIR"
+`)
+	for _, item := range items {
+		dir := "$tmp/" + item.Name
+		fmt.Fprintf(&b, "\n# %s %s\n", item.Name, item.Version)
+		fmt.Fprintf(&b, "mkdir -p \"%s\"\n", dir)
+		fmt.Fprintf(&b, "fetch %s \"%s/%s\" %s\n", shellQuote(item.URL), dir, item.Asset, item.SHA256)
+		for _, step := range item.installSteps(dir, "$BIN_DIR") {
+			b.WriteString(step + "\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// exportDockerfile writes a build stage downloading the items into /out,
+// to be copied into the final stage.
+func exportDockerfile(w io.Writer, items []exportItem, p Platform) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", exportHeader(p))
+	fmt.Fprintf(&b, "FROM %s AS ds-tools\n", exportImage)
+	b.WriteString("RUN mkdir -p /out\n")
+	for _, item := range items {
+		dir := "/tmp/" + item.Name
+		fmt.Fprintf(&b, "\n# %s %s\n", item.Name, item.Version)
+		steps := []string{
+			fmt.Sprintf(`mkdir -p "%s"`, dir),
+			fmt.Sprintf(`wget -q -O "%s/%s" %s`, dir, item.Asset, shellQuote(item.URL)),
+			fmt.Sprintf(`echo "%s  %s/%s" | sha256sum -c -`, item.SHA256, dir, item.Asset),
+		}
+		steps = append(steps, item.installSteps(dir, "/out")...)
+		steps = append(steps, fmt.Sprintf(`rm -rf "%s"`, dir))
+		fmt.Fprintf(&b, "RUN %s\n", strings.Join(steps, " \\\n    && "))
+	}
+	b.WriteString("\n# Copy the tools into the final stage with:\n")
+	b.WriteString("# COPY --from=ds-tools /out/ /usr/local/bin/\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ansibleTask is a task of the exported Ansible task list. Only one of the
+// modules is set.
+type ansibleTask struct {
+	Name      string            `yaml:"name"`
+	File      map[string]string `yaml:"ansible.builtin.file,omitempty"`
+	GetURL    map[string]string `yaml:"ansible.builtin.get_url,omitempty"`
+	Unarchive *ansibleUnarchive `yaml:"ansible.builtin.unarchive,omitempty"`
+	Copy      map[string]any    `yaml:"ansible.builtin.copy,omitempty"`
+}
+
+type ansibleUnarchive struct {
+	Src       string   `yaml:"src"`
+	Dest      string   `yaml:"dest"`
+	RemoteSrc bool     `yaml:"remote_src"`
+	Include   []string `yaml:"include"`
+}
+
+// exportAnsible writes a task list installing the items into the
+// ds_bin_dir variable, /usr/local/bin by default.
+func exportAnsible(w io.Writer, items []exportItem, p Platform) error {
+	const bin = "{{ ds_bin_dir | default('/usr/local/bin') }}"
+	var tasks []ansibleTask
+	for _, item := range items {
+		title := item.Name + " " + item.Version
+		dest := bin + "/" + item.Name
+		checksum := "sha256:" + item.SHA256
+		if item.Format == exportRaw {
+			tasks = append(tasks, ansibleTask{
+				Name:   "Install " + title,
+				GetURL: map[string]string{"url": item.URL, "dest": dest, "checksum": checksum, "mode": "0755"},
+			})
+			continue
+		}
+		dir := "/tmp/ds-export/" + item.Name
+		file := dir + "/" + item.Asset
+		tasks = append(tasks,
+			ansibleTask{
+				Name: "Create the download directory for " + item.Name,
+				File: map[string]string{"path": dir, "state": "directory", "mode": "0700"},
+			},
+			ansibleTask{
+				Name:   "Download " + title,
+				GetURL: map[string]string{"url": item.URL, "dest": file, "checksum": checksum, "mode": "0644"},
+			},
+			ansibleTask{
+				Name:      "Extract " + title,
+				Unarchive: &ansibleUnarchive{Src: file, Dest: dir, RemoteSrc: true, Include: []string{item.Member}},
+			},
+			ansibleTask{
+				Name: "Install " + title,
+				Copy: map[string]any{
+					"src":        dir + "/" + strings.TrimPrefix(path.Clean("/"+item.Member), "/"),
+					"dest":       dest,
+					"remote_src": true,
+					"mode":       "0755",
+				},
+			},
+		)
+	}
+	out, err := yaml.Marshal(tasks)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "# %s\n%s", exportHeader(p), out)
+	return err
+}
+
+// exportWriters write the items in each of ExportFormats.
+var exportWriters = map[string]func(io.Writer, []exportItem, Platform) error{
+	ExportSh:         exportSh,
+	ExportDockerfile: exportDockerfile,
+	ExportAnsible:    exportAnsible,
+}
+
+var export = &Z.Cmd{
+	Name:     `export`,
+	Summary:  `write a script installing tools without ds [requires internet]`,
+	Usage:    `[--format sh|dockerfile|ansible] [--platform OS/ARCH] [--pre] TOOL[@VERSION]...`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *export* command resolves each tool to an exact release and
+		writes the steps installing it on the platform, linux/amd64 by
+		default, for machines and containers that cannot run ds. Every
+		asset is downloaded and verified first, so the output pins the
+		download URL and SHA-256 sum of each asset and extracts only the
+		binary from archives. Writing the same tools at the same versions
+		always gives the same output.
+
+		The formats are a POSIX *sh* script installing into $BIN_DIR, a
+		*dockerfile* build stage to copy the tools from, and an *ansible*
+		task list installing into the ds_bin_dir variable. Tools released
+		only as distro packages cannot be exported and post install hooks
+		are not run.
+
+		    ds get export k9s jq > install-tools.sh
+		    ds get export --format dockerfile --platform linux/arm64 k9s@v0.27.4`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		defer tempdir.Cleanup()
+		opts, args, err := parseOptions(caller, args, "--format", "--platform", "--pre")
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 || len(opts.Platforms) > 1 {
+			return caller.UsageError()
+		}
+		format := opts.Format
+		if format == "" {
+			format = ExportSh
+		}
+		write, ok := exportWriters[format]
+		if !ok {
+			return fmt.Errorf("unknown export format %q, must be one of %s", format, strings.Join(ExportFormats, ", "))
+		}
+		p := Platform{"linux", "amd64"}
+		if len(opts.Platforms) == 1 {
+			p, err = parsePlatform(opts.Platforms[0])
+			if err != nil {
+				return err
+			}
+		}
+		if p.OS == "windows" {
+			return fmt.Errorf("cannot export for %s, only for linux and darwin", p)
+		}
+
+		tools := Registry()
+		var items []exportItem
+		for _, arg := range args {
+			name, version, _ := strings.Cut(arg, "@")
+			t, err := getTool(name, tools)
+			if err != nil {
+				return err
+			}
+			if version == "" {
+				version = t.Version
+			}
+			if version == "" {
+				version = "latest"
+			}
+			if opts.Pre {
+				t.Channel = ChannelPre
+			}
+			item, err := exportTool(&t, version, p)
+			if err != nil {
+				return err
+			}
+			log.Printf("Exported %s %s\n", item.Name, item.Version)
+			items = append(items, item)
+		}
+		return write(os.Stdout, items, p)
+	},
+}
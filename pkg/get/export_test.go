@@ -0,0 +1,118 @@
+package get
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExportTool(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	archive := gzipped(t, testTar(t, true))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/hey_linux_amd64.tar.gz":
+			w.Write(archive)
+		case "/jq-linux64":
+			w.Write([]byte("jq binary"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	for _, name := range []string{"hey", "jq"} {
+		var releases []*GithubAPIReleasesResponse
+		err := json.Unmarshal([]byte(fmt.Sprintf(`[{"tag_name": "v1.0.0", "assets": [
+			{"name": "hey_linux_amd64.tar.gz", "browser_download_url": "%[1]s/hey_linux_amd64.tar.gz"},
+			{"name": "jq-linux64", "browser_download_url": "%[1]s/jq-linux64"}
+		]}]`, srv.URL)), &releases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		releaseCache.Lock()
+		releaseCache.repos["https://api.github.com/repos/example/"+name+"/releases?per_page=100"] = releases
+		releaseCache.Unlock()
+	}
+
+	sum := func(b []byte) string {
+		s := sha256.Sum256(b)
+		return hex.EncodeToString(s[:])
+	}
+	tools := []Tool{
+		{Name: "hey", Owner: "example", Repo: "hey", BinaryTemplate: "{{.Name}}_{{.OSName}}_{{.ArchName}}.tar.gz"},
+		{Name: "jq", Owner: "example", Repo: "jq", BinaryTemplate: "jq-linux64"},
+	}
+	want := []exportItem{
+		{"hey", "v1.0.0", srv.URL + "/hey_linux_amd64.tar.gz", sum(archive), "hey_linux_amd64.tar.gz", exportTarGz, "./usr/bin/hey"},
+		{"jq", "v1.0.0", srv.URL + "/jq-linux64", sum([]byte("jq binary")), "jq-linux64", exportRaw, ""},
+	}
+	var items []exportItem
+	for i, tool := range tools {
+		item, err := exportTool(&tool, "latest", Platform{"linux", "amd64"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item != want[i] {
+			t.Errorf("got %+v, want %+v", item, want[i])
+		}
+		items = append(items, item)
+	}
+
+	for _, format := range ExportFormats {
+		var a, b bytes.Buffer
+		err := exportWriters[format](&a, items, Platform{"linux", "amd64"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		exportWriters[format](&b, items, Platform{"linux", "amd64"})
+		if a.String() != b.String() {
+			t.Errorf("%s: output is not reproducible", format)
+		}
+		for _, item := range items {
+			if !strings.Contains(a.String(), item.SHA256) {
+				t.Errorf("%s: %s is not pinned to its sum", format, item.Name)
+			}
+		}
+		if format == ExportAnsible {
+			var tasks []ansibleTask
+			err := yaml.Unmarshal(a.Bytes(), &tasks)
+			if err != nil || len(tasks) != 5 {
+				t.Errorf("ansible: %d tasks, %v", len(tasks), err)
+			}
+		}
+	}
+
+	// Run the script when the programs it needs are around.
+	for _, prog := range []string{"sh", "curl", "tar", "install"} {
+		if _, err := exec.LookPath(prog); err != nil {
+			t.Skipf("%s is not installed", prog)
+		}
+	}
+	var script bytes.Buffer
+	exportSh(&script, items, Platform{"linux", "amd64"})
+	bin := t.TempDir()
+	cmd := exec.Command("sh", "-s")
+	cmd.Stdin = &script
+	cmd.Env = append(os.Environ(), "BIN_DIR="+bin)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("script failed: %s\n%s", err, out)
+	}
+	for name, body := range map[string]string{"hey": "binary", "jq": "jq binary"} {
+		b, err := os.ReadFile(filepath.Join(bin, name))
+		if err != nil || string(b) != body {
+			t.Errorf("script installed %s as %q, %v", name, b, err)
+		}
+	}
+}
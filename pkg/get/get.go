@@ -5,36 +5,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/olekukonko/tablewriter"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	"github.com/danielmichaels/ds/pkg/web"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strings"
+	"sync"
 	"text/template"
 	"time"
 )
 
-var (
-	httpTimeout = 30 * time.Second
-)
-
-func httpClient(timeout *time.Duration) http.Client {
-	cl := http.Client{}
-	cl.Timeout = *timeout
-	return cl
-}
-
 var Cmd = &Z.Cmd{
 	Name:    `get`,
 	Summary: `install executables and applications on the host system [requires internet]`,
+	Usage:   `[--pre] [--no-hooks] [--go-install] [--output FORMAT] [--category NAME[,...]] [TOOL[@VERSION]|@GROUP...] | COMMAND`,
 	Description: `
 		The *get* command downloads a tools or applications from that providers releases or
 		downloads page. Typically, tools are downloaded as a binary for fast and efficient access
 		on the host platform.
+
+		Post-install steps that change the system beyond ds, such as *mkcert*
+		adding its root CA to the trust stores, are skipped unless the tool is
+		listed in the *get.opt_in_hooks* conf value.
 		`,
 	Other: []Z.Section{
 		{
@@ -42,37 +41,117 @@ var Cmd = &Z.Cmd{
 			Body: `
 			ds get - list all available tools
 
-			ds get arkade - download the Arkade binary`,
+			ds get arkade - download the Arkade binary
+
+			ds get @k8s - download every tool in the k8s group
+
+			ds get jq fzf - download several tools
+
+			ds get --category kubernetes - list the tools tagged kubernetes
+
+			ds get groups - list the groups and categories of tools
+
+			ds get --pre k9s - download the latest k9s including prereleases
+
+			ds get --no-hooks gh - download gh without running its post-install steps
+
+			ds get --go-install k9s - build k9s with go install if no binary matches this platform
+
+			ds get k9s@~0.27 - download the highest k9s 0.27 release
+
+			ds get hugo@">=0.110 <0.120" - download the highest hugo in a range
+
+			ds get --output json - list all available tools as JSON
+
+			ds get k9s --output json - download k9s and print the result as JSON
+
+			ds get info k9s - show details of a tool
+
+			ds get installed - list the tools installed by ds
+
+			ds get outdated - list installed tools with a newer release
+
+			ds get status - show the version of each tool on PATH, managed or not
+
+			ds get adopt k9s - bring an existing k9s binary under ds management
+
+			ds get lock k9s@v0.27.4 jq - pin tools for this project in ds.lock
+
+			ds get sync - install the tools pinned in ds.lock into the project
+
+			ds exec -- hugo server - run hugo with the project tools first on PATH
+
+			ds get shims - write shims that run the project version of each tool
+
+			ds run hey -- -n 100 http://localhost:8080 - run hey without installing it
+
+			ds get update-index - fetch the tool indexes listed in conf
+
+			ds get clean - remove temporary files and prune the run cache
+
+			ds get changelog k9s - show the release notes since the installed k9s
+
+			ds get verify-registry - check every tool against recorded releases`,
 		},
 	},
 	Commands: []*Z.Cmd{
 		// imported commands
 		help.Cmd,
 		// local
+		info, installed, outdated, status, adopt, lock, syncLock, export, execCmd, shims, run, updateIndex, clean, changelog, groupsCmd, verifyRegistry,
 	},
-	Call: func(_ *Z.Cmd, args ...string) error {
-		tools := MakeTools()
+	Call: func(caller *Z.Cmd, args ...string) error {
+		defer tempdir.Cleanup()
+		opts, args, err := parseOptions(caller, args, "--pre", "--no-hooks", "--go-install", "--output", "--category")
+		if err != nil {
+			return err
+		}
+		tools := Registry()
 		arch, opSystem := GetClientArch()
 		sort.Sort(tools)
 		if len(args) == 0 {
-			ListToolsTable(tools)
-			return nil
+			return ListTools(os.Stdout, filterTools(tools, opts.Categories), opts.Output)
+		}
+		if len(args) > 1 || strings.HasPrefix(args[0], "@") {
+			groups, err := LoadGroups()
+			if err != nil {
+				return err
+			}
+			names, err := expandTools(args, tools, groups)
+			if err != nil {
+				return err
+			}
+			return installTools(names, tools, opts)
 		}
-		tool := args[0]
+		tool, constraint, _ := strings.Cut(args[0], "@")
 		log.Printf("Looking up version for %q\n", tool)
 		t, err := getTool(tool, tools)
 		if err != nil {
 			return err
 		}
+		if constraint != "" {
+			t.Version = constraint
+		}
+		opts.apply(&t)
 
 		version := t.Version
 		if version == "" {
 			version = "latest"
 		}
-		_, err = Download(&t, arch, opSystem, version)
+		if opts.Output == OutputTable {
+			printUpgradeNotes(&t, version)
+		}
+		res, err := install(&t, arch, opSystem, version, opts)
 		if err != nil {
 			return err
 		}
+		if opts.Output != OutputTable {
+			return view{
+				Header: []string{"Tool", "Version", "Path", "SHA256"},
+				Rows:   [][]string{{res.Name, res.Version, res.Path, res.SHA256}},
+				Value:  res,
+			}.write(os.Stdout, opts.Output)
+		}
 
 		err = PrintPostInstallMessage(t)
 		if err != nil {
@@ -83,43 +162,124 @@ var Cmd = &Z.Cmd{
 	},
 }
 
+// releaseCache remembers the releases fetched for each repository so a command
+// looking them up more than once only asks GitHub the first time.
+var releaseCache = struct {
+	sync.Mutex
+	repos map[string][]*GithubAPIReleasesResponse
+}{repos: map[string][]*GithubAPIReleasesResponse{}}
+
 // FindGithubRelease retrieves a response from GitHub's API for any valid repository
-// in JSON format.
+// in JSON format. Responses are cached on disk, see githubGetCached.
 func FindGithubRelease(owner, repo string) ([]*GithubAPIReleasesResponse, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", owner, repo)
-	cl := httpClient(&httpTimeout)
-
-	r, err := http.NewRequest(http.MethodGet, url, nil)
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/releases?per_page=100", owner, repo)
+	releaseCache.Lock()
+	defer releaseCache.Unlock()
+	if cached, ok := releaseCache.repos[url]; ok {
+		return cached, nil
+	}
+	body, err := githubGetCached(url)
 	if err != nil {
 		return nil, err
 	}
+	var release []*GithubAPIReleasesResponse
+	err = json.Unmarshal(body, &release)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release with err: %s", err)
+	}
+	releaseCache.repos[url] = release
+	return release, nil
+}
 
-	res, err := cl.Do(r)
+// GithubToken returns the token used to authenticate with the GitHub API,
+// taken from $GITHUB_TOKEN, $GH_TOKEN or the github.token conf value in that
+// order. Authenticated requests have a much higher rate limit.
+func GithubToken() string {
+	for _, env := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		if token := os.Getenv(env); token != "" {
+			return token
+		}
+	}
+	if Z.Conf == nil {
+		return ""
+	}
+	token, err := Z.Conf.Query(".github.token")
+	if err != nil {
+		return ""
+	}
+	token = strings.TrimSpace(token)
+	if token == "null" {
+		return ""
+	}
+	return token
+}
+
+// githubGet requests a GitHub API url, authenticated when a GithubToken is
+// available.
+func githubGet(url string) (*http.Response, error) {
+	req, err := newGithubRequest(url)
 	if err != nil {
 		return nil, err
 	}
+	return web.Client().Do(req)
+}
 
-	if res.Body != nil {
-		defer res.Body.Close()
+// newGithubRequest returns a GET request for a GitHub API url.
+func newGithubRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := GithubToken(); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
+	return req, nil
+}
 
-	if res.StatusCode != 200 {
+// RateLimit is the GitHub API rate limit for the current token, or the
+// client address when there is no token.
+type RateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"-"`
+}
+
+// GithubRateLimit returns the core GitHub API rate limit. Checking it does
+// not count against the limit.
+func GithubRateLimit() (*RateLimit, error) {
+	res, err := githubGet("https://api.github.com/rate_limit")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", res.StatusCode)
 	}
-	var release []*GithubAPIReleasesResponse
-	//var r2 any
-	err = json.NewDecoder(res.Body).Decode(&release)
+	var body struct {
+		Resources struct {
+			Core struct {
+				RateLimit
+				Reset int64 `json:"reset"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode release with err: %s", err)
+		return nil, err
 	}
-	return release, nil
+	core := body.Resources.Core
+	rl := core.RateLimit
+	rl.Reset = time.Unix(core.Reset, 0)
+	return &rl, nil
 }
 
 func PrintPostInstallMessage(t Tool) error {
 	lt := ToolLocal{
 		Name:    t.Name,
-		Path:    fmt.Sprintf("%s/%s/%s", os.Getenv("HOME"), toolFilePath, t.Name),
-		BinPath: fmt.Sprintf("%s", toolFilePath),
+		Path:    filepath.Join(BinDir(), t.Name),
+		BinPath: BinDir(),
+		OnPath:  OnPath(BinDir()),
 	}
 	msg, err := PostInstallationMessage(lt)
 	if err != nil {
@@ -131,25 +291,34 @@ func PrintPostInstallMessage(t Tool) error {
 
 // ListToolsTable returns a list of all supported tools in tabular format.
 func ListToolsTable(tools Tools) {
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetColWidth(60)
-	table.SetHeader([]string{"Tool", "Description"})
-	count := 0
+	_ = ListTools(os.Stdout, tools, OutputTable)
+}
+
+// toolSummary is a tool as shown in listings.
+type toolSummary struct {
+	Name        string   `json:"name" yaml:"name"`
+	Description string   `json:"description" yaml:"description"`
+	Repo        string   `json:"repo" yaml:"repo"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// ListTools writes a list of all supported tools to w in the given format.
+func ListTools(w io.Writer, tools Tools, format string) error {
+	v := view{
+		Header:  []string{"Tool", "Description", "Tags"},
+		Value:   []toolSummary{},
+		Caption: fmt.Sprintf("%d tools are currently supported.\n", len(tools)),
+	}
 	for _, tool := range tools {
-		table.Append([]string{tool.Name, tool.Description})
-		count++
-	}
-	table.SetHeaderColor(
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgGreenColor},
-		tablewriter.Colors{tablewriter.Bold, tablewriter.Normal},
-	)
-	table.SetColumnColor(
-		tablewriter.Colors{tablewriter.Bold, tablewriter.FgHiGreenColor},
-		tablewriter.Colors{tablewriter.Normal, tablewriter.Normal},
-	)
-	table.SetRowLine(true)
-	table.SetCaption(true, fmt.Sprintf("%d tools are currently supported.\n", count))
-	table.Render()
+		v.Rows = append(v.Rows, []string{tool.Name, tool.Description, strings.Join(tool.Tags, ", ")})
+		v.Value = append(v.Value.([]toolSummary), toolSummary{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Repo:        tool.Owner + "/" + tool.Repo,
+			Tags:        tool.Tags,
+		})
+	}
+	return v.write(w, format)
 }
 
 // getTool retrieves tool information from the all the available Tool structs
@@ -162,9 +331,18 @@ func getTool(tool string, tools Tools) (Tool, error) {
 				Repo:           t.Repo,
 				Owner:          t.Owner,
 				Version:        t.Version,
+				Channel:        t.Channel,
 				Description:    t.Description,
+				Tags:           t.Tags,
 				NonBinary:      t.NonBinary,
 				BinaryTemplate: t.BinaryTemplate,
+				OSNames:        t.OSNames,
+				ArchNames:      t.ArchNames,
+				Ext:            t.Ext,
+				Verify:         t.Verify,
+				PostInstall:    t.PostInstall,
+				VersionProbe:   t.VersionProbe,
+				GoModule:       t.GoModule,
 			}, nil
 		}
 	}
@@ -175,33 +353,41 @@ func getTool(tool string, tools Tools) (Tool, error) {
 // error if the tool's template cannot be parsed or executed.
 func GetBinaryName(tool *Tool, os, arch, version string) (string, error) {
 	if len(tool.BinaryTemplate) > 0 {
-		var err error
-		t := template.New(tool.Name + "_binaryname")
-		t = t.Funcs(templateFuncs)
+		return renderTemplate(tool.Name+"_binaryname", tool.BinaryTemplate, templateData(tool, os, arch, version))
+	}
 
-		t, err = t.Parse(tool.BinaryTemplate)
-		if err != nil {
-			return "", err
-		}
+	return "", errors.New("BinaryTemplate is not set")
+}
 
-		var buf bytes.Buffer
-		ver := toolVersion(tool, version)
-		if err := t.Execute(&buf, map[string]string{
-			"OS":            os,
-			"Arch":          arch,
-			"Name":          tool.Name,
-			"Version":       ver,
-			"VersionNumber": strings.TrimPrefix(ver, "v"),
-		}); err != nil {
-			return "", err
-		}
+// templateData returns the values available to a tool's templates.
+func templateData(tool *Tool, os, arch, version string) map[string]string {
+	ver := toolVersion(tool, version)
+	osName, archName, ext := tool.platformNames(os, arch)
+	return map[string]string{
+		"OS":            os,
+		"Arch":          arch,
+		"OSName":        osName,
+		"ArchName":      archName,
+		"Ext":           ext,
+		"Name":          tool.Name,
+		"Version":       ver,
+		"VersionNumber": strings.TrimPrefix(ver, "v"),
+	}
+}
 
-		res := strings.TrimSpace(buf.String())
-		fmt.Printf("[DEBUG] binaryName %q\n", res)
-		return res, nil
+// renderTemplate executes tmpl with templateFuncs and trims the result.
+func renderTemplate(name, tmpl string, data map[string]string) (string, error) {
+	t, err := template.New(name).Funcs(templateFuncs).Parse(tmpl)
+	if err != nil {
+		return "", err
 	}
 
-	return "", errors.New("BinaryTemplate is not set")
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(buf.String()), nil
 }
 
 func toolVersion(tool *Tool, version string) string {
@@ -213,17 +399,30 @@ func toolVersion(tool *Tool, version string) string {
 }
 
 // GetClientArch retrieves the host systems architecture and operating system.
-// It will change the naming to a consistent format for linux/amd64. Further
-// transformations of the architecture and operating system are then done elsewhere
-// from a common base.
+// The names are converted to the uname style (x86_64, aarch64, mingw) that
+// every BinaryTemplate is written against. See clientArch.
 func GetClientArch() (arch, os string) {
-	os = runtime.GOOS
-	if runtime.GOOS == "linux" {
-		os = "linux"
+	return clientArch(runtime.GOOS, runtime.GOARCH)
+}
+
+// clientArch converts a Go runtime operating system and architecture into
+// the names passed to a BinaryTemplate. Linux reports 64-bit arm as aarch64
+// whereas macOS reports arm64, and windows is matched by its "ming" prefix.
+func clientArch(goos, goarch string) (arch, os string) {
+	os = goos
+	if goos == "windows" {
+		os = "mingw"
 	}
-	arch = runtime.GOARCH
-	if runtime.GOARCH == "amd64" {
+	arch = goarch
+	switch goarch {
+	case "amd64":
 		arch = "x86_64"
+	case "arm64":
+		if goos == "linux" {
+			arch = "aarch64"
+		}
+	case "arm":
+		arch = "armv7l"
 	}
 	return arch, os
 }
@@ -231,34 +430,139 @@ func GetClientArch() (arch, os string) {
 // GetDownloadURL returns the downloadable assets from GitHub for use in other
 // functions.
 func GetDownloadURL(tool Tool, arch, opSystem, version string) (string, error) {
-	releases, err := FindGithubRelease(tool.Owner, tool.Repo)
+	asset, err := ResolveAsset(tool, arch, opSystem, version)
 	if err != nil {
 		return "", err
 	}
-	if version == "latest" {
-		// get then latest tag which is always index 0
-		version = releases[0].TagName
+	return asset.URL, nil
+}
+
+// Asset is a release asset resolved for a tool on a platform along with the
+// release it belongs to.
+type Asset struct {
+	Name    string
+	URL     string
+	Version string
+	OS      string
+	Arch    string
+	Release *GithubAPIReleasesResponse
+}
+
+// find returns the download URL of the named asset in the same release.
+func (a *Asset) find(name string) (string, bool) {
+	for _, asset := range a.Release.Assets {
+		if asset.Name == name {
+			return asset.BrowserDownloadUrl, true
+		}
 	}
+	return "", false
+}
 
-	binaryName, err := GetBinaryName(&tool, opSystem, arch, version)
+// ResolveAsset finds the release matching version and the asset in it named
+// by the tool's BinaryTemplate for the given platform.
+func ResolveAsset(tool Tool, arch, opSystem, version string) (*Asset, error) {
+	release, err := resolveRelease(&tool, version)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
 	defer func() {
-		log.Printf("Found version %q\n", version)
+		log.Printf("Found version %q\n", release.TagName)
 	}()
+	return releaseAsset(&tool, release, arch, opSystem)
+}
+
+// resolveRelease returns the release of the tool matching version.
+func resolveRelease(tool *Tool, version string) (*GithubAPIReleasesResponse, error) {
+	releases, err := FindGithubRelease(tool.Owner, tool.Repo)
+	if err != nil {
+		return nil, err
+	}
+	release, err := selectRelease(releases, version, tool.Channel == ChannelPre)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", tool.Name, err)
+	}
+	return release, nil
+}
+
+// releaseAsset returns the asset of release named by the tool's
+// BinaryTemplate for the given platform. For NonBinary tools it is the
+// script at that path in the repository at the release tag instead.
+func releaseAsset(tool *Tool, release *GithubAPIReleasesResponse, arch, opSystem string) (*Asset, error) {
+	version := release.TagName
+	binaryName, err := GetBinaryName(tool, opSystem, arch, version)
+	if err != nil {
+		return nil, err
+	}
 
+	asset := &Asset{
+		Name:    binaryName,
+		Version: version,
+		OS:      opSystem,
+		Arch:    arch,
+		Release: release,
+	}
+	if tool.NonBinary {
+		asset.URL = scriptURL(tool, version, binaryName)
+		return asset, nil
+	}
+	url, ok := asset.find(binaryName)
+	if !ok {
+		return nil, fmt.Errorf("%w for %s", ErrNoAsset, tool.Name)
+	}
+	asset.URL = url
+	return asset, nil
+}
+
+// selectRelease returns the release for version. When version is "latest"
+// the newest release that is not a draft is returned, skipping prereleases
+// unless pre is set. Otherwise version must match a release name or tag, or
+// be a constraint such as "~0.27" in which case the highest matching release
+// is returned.
+func selectRelease(releases []*GithubAPIReleasesResponse, version string, pre bool) (*GithubAPIReleasesResponse, error) {
 	for _, release := range releases {
-		if release.Name == version || release.TagName == version {
-			for _, asset := range release.Assets {
-				if asset.Name == binaryName {
-					return asset.BrowserDownloadUrl, nil
-				}
+		if release.Draft {
+			continue
+		}
+		if version == "latest" {
+			if release.Prerelease && !pre {
+				continue
 			}
+			return release, nil
+		}
+		if release.Name == version || release.TagName == version {
+			return release, nil
 		}
 	}
-	return "", fmt.Errorf("no download URL found for %s", tool.Name)
+	if version == "latest" && !pre {
+		return nil, errors.New("no stable release found, use --pre to include prereleases")
+	}
+	if version == "latest" {
+		return nil, errors.New("no releases found")
+	}
+
+	c, err := parseConstraint(version)
+	if err != nil {
+		return nil, fmt.Errorf("release %q not found", version)
+	}
+	pre = pre || c.allowsPre()
+	var best *GithubAPIReleasesResponse
+	var bestVer semver
+	for _, release := range releases {
+		if release.Draft {
+			continue
+		}
+		v, ok := parseSemver(release.TagName)
+		if !ok || ((release.Prerelease || v.Pre != "") && !pre) || !c.check(v) {
+			continue
+		}
+		if best == nil || v.compare(bestVer) > 0 {
+			best, bestVer = release, v
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no release matches %q", version)
+	}
+	return best, nil
 }
 
 // GithubAPIReleasesResponse is taken from the GitHub Releases API
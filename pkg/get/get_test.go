@@ -0,0 +1,91 @@
+package get
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectRelease(t *testing.T) {
+	releases := []*GithubAPIReleasesResponse{
+		{TagName: "v0.28.0-rc.1", Prerelease: true},
+		{TagName: "v0.27.5", Draft: true},
+		{TagName: "v0.27.4", Name: "Release 0.27.4"},
+		{TagName: "v0.27.3"},
+		{TagName: "v0.26.7"},
+	}
+
+	tt := []struct {
+		version string
+		pre     bool
+		want    string
+	}{
+		{"latest", false, "v0.27.4"},
+		{"latest", true, "v0.28.0-rc.1"},
+		{"v0.26.7", false, "v0.26.7"},
+		{"Release 0.27.4", false, "v0.27.4"},
+		{"v0.28.0-rc.1", false, "v0.28.0-rc.1"},
+		{"~0.27", false, "v0.27.4"},
+		{"~0.26", false, "v0.26.7"},
+		{">=0.27", true, "v0.28.0-rc.1"},
+	}
+
+	for _, tc := range tt {
+		r, err := selectRelease(releases, tc.version, tc.pre)
+		if err != nil {
+			t.Errorf("%q: %v", tc.version, err)
+			continue
+		}
+		if r.TagName != tc.want {
+			t.Errorf("%q: got %s, want %s", tc.version, r.TagName, tc.want)
+		}
+	}
+
+	for _, version := range []string{"v0.27.5", "~0.25"} {
+		if _, err := selectRelease(releases, version, false); err == nil {
+			t.Errorf("%q: expected an error when no release matches", version)
+		}
+	}
+}
+
+func TestSelectReleaseChannel(t *testing.T) {
+	releases := []*GithubAPIReleasesResponse{
+		{TagName: "v2.0.0", Draft: true},
+		{TagName: "v2.0.0-beta.2", Prerelease: true},
+		{TagName: "v2.0.0-beta.1", Prerelease: true},
+	}
+
+	_, err := selectRelease(releases, "latest", false)
+	if err == nil || !strings.Contains(err.Error(), "--pre") {
+		t.Errorf("stable channel: got %v, want a hint to use --pre", err)
+	}
+	r, err := selectRelease(releases, "latest", true)
+	if err != nil || r.TagName != "v2.0.0-beta.2" {
+		t.Errorf("pre channel: got %v, %v", r, err)
+	}
+	_, err = selectRelease(releases[:1], "latest", true)
+	if err == nil {
+		t.Error("pre channel with only drafts: expected an error")
+	}
+
+	releaseCache.Lock()
+	releaseCache.repos["https://api.github.com/repos/example/beta/releases?per_page=100"] = releases
+	releaseCache.Unlock()
+	tt := []struct {
+		channel string
+		want    string
+	}{
+		{"", ""},
+		{ChannelStable, ""},
+		{ChannelPre, "v2.0.0-beta.2"},
+	}
+	for _, tc := range tt {
+		tool := &Tool{Name: "beta", Owner: "example", Repo: "beta", Channel: tc.channel}
+		r, err := resolveRelease(tool, "latest")
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("%q channel: got %s, want an error", tc.channel, r.TagName)
+		case tc.want != "" && (err != nil || r.TagName != tc.want):
+			t.Errorf("%q channel: got %v, %v, want %s", tc.channel, r, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package get
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"time"
+)
+
+// ErrNoAsset is returned when a release has no asset for the platform.
+var ErrNoAsset = errors.New("no download URL found")
+
+// majorSuffix matches the major version element ending a Go module path.
+var majorSuffix = regexp.MustCompile(`^v\d+$`)
+
+// goBinaryName returns the name go install gives the binary built from
+// the package at pkg, which is its last element ignoring a major version.
+func goBinaryName(pkg string) string {
+	name := path.Base(pkg)
+	if majorSuffix.MatchString(name) {
+		name = path.Base(path.Dir(pkg))
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// goInstallEnabled reports whether tools without an asset for the platform
+// may be built from source, with --go-install or the get.go_install conf
+// value.
+func goInstallEnabled(opts options) bool {
+	return opts.GoInstall || confString(".get.go_install") == "true"
+}
+
+// GoInstall builds the tool's GoModule at the release matching version with
+// "go install" into the bin directory and records it like a downloaded
+// install.
+func GoInstall(tool *Tool, version string) (*Installed, error) {
+	if tool.GoModule == "" {
+		return nil, fmt.Errorf("%s cannot be built from source", tool.Name)
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		return nil, fmt.Errorf("building %s from source requires go to be installed", tool.Name)
+	}
+	release, err := resolveRelease(tool, version)
+	if err != nil {
+		return nil, err
+	}
+	tag := release.TagName
+
+	l, err := lockTool(tool.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+
+	binDir, err := InitUserDir()
+	if err != nil {
+		return nil, err
+	}
+	target := tool.GoModule + "@" + tag
+	log.Printf("Building %s with go install %s\n", tool.Name, target)
+	cmd := exec.Command(goBin, "install", target)
+	cmd.Env = append(os.Environ(), "GOBIN="+binDir)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("go install %s: %w", target, err)
+	}
+
+	localPath, err := LocalBinary(tool.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	built := filepath.Join(binDir, goBinaryName(tool.GoModule))
+	if built != localPath {
+		err = os.Rename(built, localPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	sum, err := sha256File(localPath)
+	if err != nil {
+		return nil, err
+	}
+	res := &Installed{
+		Name:        tool.Name,
+		Version:     tag,
+		Path:        localPath,
+		URL:         "go:" + target,
+		SHA256:      sum,
+		InstalledAt: time.Now().UTC(),
+	}
+	err = recordInstall(*res)
+	if err != nil {
+		return nil, err
+	}
+	err = RunPostInstall(tool, localPath, tag)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// install downloads the tool, falling back to building it from source when
+// there is no asset for the platform and opts allow it.
+func install(tool *Tool, arch, opSystem, version string, opts options) (*Installed, error) {
+	res, err := Download(tool, arch, opSystem, version)
+	if !errors.Is(err, ErrNoAsset) || tool.GoModule == "" {
+		return res, err
+	}
+	if !goInstallEnabled(opts) {
+		return nil, fmt.Errorf("%w, use --go-install to build it from source", err)
+	}
+	log.Printf("%s, building it from source\n", err)
+	return GoInstall(tool, version)
+}
@@ -0,0 +1,96 @@
+package get
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGoBinaryName(t *testing.T) {
+	tt := map[string]string{
+		"github.com/derailed/k9s":             "k9s",
+		"github.com/cli/cli/v2/cmd/gh":        "gh",
+		"github.com/goreleaser/goreleaser/v2": "goreleaser",
+		"filippo.io/mkcert":                   "mkcert",
+	}
+	for pkg, want := range tt {
+		if runtime.GOOS == "windows" {
+			want += ".exe"
+		}
+		if got := goBinaryName(pkg); got != want {
+			t.Errorf("goBinaryName(%s) = %s, want %s", pkg, got, want)
+		}
+	}
+}
+
+// writeGoProxy writes a module proxy to dir serving example.com/hello at
+// version with a main package printing it.
+func writeGoProxy(t *testing.T, dir, version string) {
+	base := filepath.Join(dir, "example.com", "hello", "@v")
+	if err := os.MkdirAll(base, 0700); err != nil {
+		t.Fatal(err)
+	}
+	mod := "module example.com/hello\n\ngo 1.18\n"
+	main := "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println(\"hello " + version + "\") }\n"
+	os.WriteFile(filepath.Join(base, "list"), []byte(version+"\n"), 0600)
+	os.WriteFile(filepath.Join(base, version+".info"), []byte(`{"Version":"`+version+`"}`), 0600)
+	os.WriteFile(filepath.Join(base, version+".mod"), []byte(mod), 0600)
+	f, err := os.Create(filepath.Join(base, version+".zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, body := range map[string]string{"go.mod": mod, "main.go": main} {
+		w, _ := zw.Create("example.com/hello@" + version + "/" + name)
+		w.Write([]byte(body))
+	}
+	zw.Close()
+	f.Close()
+}
+
+func TestInstallGoFallback(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a module")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	t.Setenv("HOME", t.TempDir())
+	proxy := t.TempDir()
+	writeGoProxy(t, proxy, "v1.0.0")
+	t.Setenv("GOPROXY", "file://"+filepath.ToSlash(proxy))
+	t.Setenv("GOSUMDB", "off")
+	t.Setenv("GOFLAGS", "-modcacherw")
+	t.Setenv("GOMODCACHE", t.TempDir())
+
+	tool := &Tool{Name: "hello", Owner: "example", Repo: "hello", BinaryTemplate: "hello-{{.OS}}", GoModule: "example.com/hello"}
+	releaseCache.Lock()
+	releaseCache.repos["https://api.github.com/repos/example/hello/releases?per_page=100"] = []*GithubAPIReleasesResponse{{TagName: "v1.0.0"}}
+	releaseCache.Unlock()
+
+	_, err := install(tool, "x86_64", "plan9", "latest", options{})
+	if !errors.Is(err, ErrNoAsset) || !strings.Contains(err.Error(), "--go-install") {
+		t.Fatalf("install without --go-install = %v", err)
+	}
+
+	in, err := install(tool, "x86_64", "plan9", "latest", options{GoInstall: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Version != "v1.0.0" || in.URL != "go:example.com/hello@v1.0.0" || in.Path != filepath.Join(BinDir(), "hello") {
+		t.Errorf("installed %+v", in)
+	}
+	out, err := exec.Command(in.Path).Output()
+	if err != nil || string(out) != "hello v1.0.0\n" {
+		t.Errorf("built binary printed %q, %v", out, err)
+	}
+	st, _ := LoadState()
+	if st.Tools["hello"].SHA256 != in.SHA256 {
+		t.Error("go install was not recorded in the state file")
+	}
+}
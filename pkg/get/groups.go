@@ -0,0 +1,247 @@
+package get
+
+import (
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Groups are the built in named groups of tools installed together with
+// "ds get @NAME". Groups in the get.groups conf value are added to them,
+// replacing any with the same name.
+//
+//	get:
+//	  groups:
+//	    sre: [k9s, stern, jq, gh]
+var Groups = map[string][]string{
+	"k8s": {"k9s", "popeye", "stern", "argocd", "k3sup"},
+	"git": {"gh", "lazygit"},
+	"web": {"hugo", "mkcert", "curlie", "hey"},
+}
+
+// LoadGroups returns the built in Groups merged with those in conf.
+func LoadGroups() (map[string][]string, error) {
+	groups := map[string][]string{}
+	for name, tools := range Groups {
+		groups[name] = tools
+	}
+	out := confString(".get.groups")
+	if out == "" {
+		return groups, nil
+	}
+	var conf map[string][]string
+	err := yaml.Unmarshal([]byte(out), &conf)
+	if err != nil {
+		return nil, fmt.Errorf("conf get.groups: %w", err)
+	}
+	for name, tools := range conf {
+		groups[name] = tools
+	}
+	return groups, nil
+}
+
+// hasTag reports whether the tool is tagged with category.
+func (tool Tool) hasTag(category string) bool {
+	return contains(tool.Tags, category)
+}
+
+// filterTools returns the tools tagged with any of categories, or all of
+// them when there are none.
+func filterTools(tools Tools, categories []string) Tools {
+	if len(categories) == 0 {
+		return tools
+	}
+	var list Tools
+	for _, t := range tools {
+		for _, c := range categories {
+			if t.hasTag(c) {
+				list = append(list, t)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// expandTools replaces each "@NAME" in args with the tools in the group of
+// that name or, when there is no such group, the tools tagged with NAME.
+// Other arguments, which may be "TOOL@VERSION", are kept as they are and
+// duplicates are dropped, keeping the version when a tool is named both
+// with one and through a group. A tool pinned to two different versions is
+// an error.
+func expandTools(args []string, tools Tools, groups map[string][]string) ([]string, error) {
+	var names []string
+	add := func(arg string) error {
+		name, constraint, _ := strings.Cut(arg, "@")
+		for i, n := range names {
+			existing, pinned, _ := strings.Cut(n, "@")
+			if existing != name {
+				continue
+			}
+			switch {
+			case constraint == "" || constraint == pinned:
+			case pinned == "":
+				names[i] = arg
+			default:
+				return fmt.Errorf("%s is pinned to both %s and %s", name, pinned, constraint)
+			}
+			return nil
+		}
+		names = append(names, arg)
+		return nil
+	}
+	for _, arg := range args {
+		name := strings.TrimPrefix(arg, "@")
+		if name == arg {
+			if err := add(arg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if members, ok := groups[name]; ok {
+			for _, m := range members {
+				if err := add(m); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		tagged := filterTools(tools, []string{name})
+		if len(tagged) == 0 {
+			return nil, fmt.Errorf("no group or category named %q", name)
+		}
+		for _, t := range tagged {
+			if err := add(t.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return names, nil
+}
+
+// installTools installs each named tool, carrying on past failures, and
+// reports the result of each. Names may be "TOOL@VERSION" to install a
+// version or constraint other than the tool's default.
+func installTools(names []string, tools Tools, opts options) error {
+	arch, opSystem := GetClientArch()
+	v := view{Header: []string{"Tool", "Version", "Path", "Status"}}
+	var list []Installed
+	failed := 0
+	for _, arg := range names {
+		name, constraint, _ := strings.Cut(arg, "@")
+		log.Printf("Looking up version for %q\n", name)
+		t, err := getTool(name, tools)
+		if err == nil {
+			if constraint != "" {
+				t.Version = constraint
+			}
+			opts.apply(&t)
+			version := t.Version
+			if version == "" {
+				version = "latest"
+			}
+			if opts.Output == OutputTable {
+				printUpgradeNotes(&t, version)
+			}
+			var res *Installed
+			res, err = install(&t, arch, opSystem, version, opts)
+			if err == nil {
+				list = append(list, *res)
+				v.Rows = append(v.Rows, []string{res.Name, res.Version, res.Path, "installed"})
+				continue
+			}
+		}
+		failed++
+		log.Printf("Failed to install %s: %s\n", name, err)
+		v.Rows = append(v.Rows, []string{name, "", "", err.Error()})
+	}
+	v.Value = list
+	if list == nil {
+		v.Value = []Installed{}
+	}
+	v.Caption = fmt.Sprintf("%d of %d tools installed.\n", len(names)-failed, len(names))
+	err := v.write(os.Stdout, opts.Output)
+	if err != nil {
+		return err
+	}
+	if opts.Output == OutputTable && !OnPath(BinDir()) {
+		fmt.Printf("\n%s is not on your PATH, add it with: eval \"$(ds shellenv)\"\n", BinDir())
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tools failed to install", failed, len(names))
+	}
+	return nil
+}
+
+// group is a named set of tools shown by the groups command.
+type group struct {
+	Name  string   `json:"name" yaml:"name"`
+	Kind  string   `json:"kind" yaml:"kind"`
+	Tools []string `json:"tools" yaml:"tools"`
+}
+
+var groupsCmd = &Z.Cmd{
+	Name:     `groups`,
+	Summary:  `list the groups and categories of tools`,
+	Usage:    `[--output FORMAT]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *groups* command lists the named groups of tools and the
+		categories tools are tagged with. Either can be installed at once
+		with *ds get @NAME*, a group taking precedence over a category of the
+		same name. Groups are added or replaced with the *get.groups* conf
+		value:
+
+		    get:
+		      groups:
+		        sre: [k9s, stern, jq, gh]`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		groups, err := LoadGroups()
+		if err != nil {
+			return err
+		}
+		tools := Registry()
+		sort.Sort(tools)
+
+		var list []group
+		for name, members := range groups {
+			list = append(list, group{Name: name, Kind: "group", Tools: members})
+		}
+		categories := map[string][]string{}
+		for _, t := range tools {
+			for _, tag := range t.Tags {
+				categories[tag] = append(categories[tag], t.Name)
+			}
+		}
+		for name, members := range categories {
+			list = append(list, group{Name: name, Kind: "category", Tools: members})
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Kind != list[j].Kind {
+				return list[i].Kind > list[j].Kind
+			}
+			return list[i].Name < list[j].Name
+		})
+
+		v := view{Header: []string{"Name", "Kind", "Tools"}, Value: list}
+		if list == nil {
+			v.Value = []group{}
+		}
+		for _, g := range list {
+			v.Rows = append(v.Rows, []string{"@" + g.Name, g.Kind, strings.Join(g.Tools, ", ")})
+		}
+		return v.write(os.Stdout, opts.Output)
+	},
+}
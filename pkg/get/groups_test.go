@@ -0,0 +1,126 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGroupsExist(t *testing.T) {
+	tools := MakeTools()
+	for name, members := range Groups {
+		for _, m := range members {
+			if _, err := getTool(m, tools); err != nil {
+				t.Errorf("group %s: %s", name, err)
+			}
+		}
+	}
+	for _, tool := range tools {
+		if len(tool.Tags) == 0 {
+			t.Errorf("%s has no tags", tool.Name)
+		}
+	}
+}
+
+func TestExpandTools(t *testing.T) {
+	tools := Tools{
+		{Name: "k9s", Tags: []string{"kubernetes"}},
+		{Name: "stern", Tags: []string{"kubernetes", "logs"}},
+		{Name: "gh", Tags: []string{"git"}},
+		{Name: "jq", Tags: []string{"json"}},
+	}
+	groups := map[string][]string{"k8s": {"k9s", "stern"}}
+	tt := []struct {
+		args []string
+		want []string
+		err  bool
+	}{
+		{[]string{"jq"}, []string{"jq"}, false},
+		{[]string{"@k8s", "jq"}, []string{"k9s", "stern", "jq"}, false},
+		{[]string{"@git", "gh"}, []string{"gh"}, false},
+		{[]string{"stern", "@kubernetes"}, []string{"stern", "k9s"}, false},
+		{[]string{"k9s@v0.27.4", "jq"}, []string{"k9s@v0.27.4", "jq"}, false},
+		{[]string{"k9s@v0.27.4", "@k8s"}, []string{"k9s@v0.27.4", "stern"}, false},
+		{[]string{"@k8s", "k9s@~0.27", "k9s"}, []string{"k9s@~0.27", "stern"}, false},
+		{[]string{"k9s@v0.27.4", "@k8s", "k9s@v0.27.4"}, []string{"k9s@v0.27.4", "stern"}, false},
+		{[]string{"k9s@v0.27.4", "k9s@v0.28.0"}, nil, true},
+		{[]string{"@nope"}, nil, true},
+	}
+	for _, tc := range tt {
+		got, err := expandTools(tc.args, tools, groups)
+		if (err != nil) != tc.err || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("expandTools(%v) = %v, %v, want %v", tc.args, got, err, tc.want)
+		}
+	}
+
+	var names []string
+	for _, tool := range filterTools(tools, []string{"logs", "git"}) {
+		names = append(names, tool.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"stern", "gh"}) {
+		t.Errorf("filterTools = %v", names)
+	}
+}
+
+func TestParseCategory(t *testing.T) {
+	opts, rest, err := parseOptions(Cmd, []string{"--category", "kubernetes,git", "--category=docs"}, "--category")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(opts.Categories, []string{"kubernetes", "git", "docs"}) || len(rest) != 0 {
+		t.Errorf("parseOptions = %v, %v", opts.Categories, rest)
+	}
+}
+
+func TestInstallTools(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "#!/bin/sh\necho %s\n", r.URL.Path)
+	}))
+	defer srv.Close()
+
+	for _, name := range []string{"alpha", "beta"} {
+		var releases []*GithubAPIReleasesResponse
+		err := json.Unmarshal([]byte(fmt.Sprintf(`[
+			{"tag_name": "v2.0.0", "assets": [{"name": "%[2]s", "browser_download_url": "%[1]s/v2/%[2]s"}]},
+			{"tag_name": "v1.0.0", "assets": [{"name": "%[2]s", "browser_download_url": "%[1]s/v1/%[2]s"}]}
+		]`, srv.URL, name)), &releases)
+		if err != nil {
+			t.Fatal(err)
+		}
+		releaseCache.Lock()
+		releaseCache.repos["https://api.github.com/repos/example/"+name+"/releases?per_page=100"] = releases
+		releaseCache.Unlock()
+	}
+	tools := Tools{
+		{Name: "alpha", Owner: "example", Repo: "alpha", BinaryTemplate: "{{.Name}}", Tags: []string{"greek"}},
+		{Name: "beta", Owner: "example", Repo: "beta", BinaryTemplate: "{{.Name}}", Tags: []string{"greek"}},
+	}
+
+	names, err := expandTools([]string{"alpha@v1.0.0", "@greek"}, tools, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []Installed
+	out, _ := captureOutput(t, func() {
+		err = installTools(names, tools, options{Output: OutputJSON})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(out), &list); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	want := map[string]string{"alpha": "v1.0.0", "beta": "v2.0.0"}
+	if len(list) != len(want) {
+		t.Fatalf("installed %+v", list)
+	}
+	for _, in := range list {
+		if in.Version != want[in.Name] {
+			t.Errorf("%s: installed %s, want %s", in.Name, in.Version, want[in.Name])
+		}
+	}
+}
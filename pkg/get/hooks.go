@@ -0,0 +1,188 @@
+package get
+
+import (
+	"bytes"
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+var (
+	completionsPath = ".ds/share/completions"
+	dockerPluginDir = ".docker/cli-plugins"
+)
+
+const (
+	// HookCompletion runs the installed binary with Args, once for each
+	// of the Shells, and writes the output to the ds completions directory.
+	HookCompletion = "completion"
+
+	// HookMkdir creates the directory named by Args.
+	HookMkdir = "mkdir"
+
+	// HookDockerPlugin installs the binary as a docker CLI plugin named by
+	// Args, or the tool name when Args is empty.
+	HookDockerPlugin = "docker-cli-plugin"
+)
+
+// Shells are the shells completions are generated for.
+var Shells = []string{"bash", "zsh", "fish"}
+
+// Hook is a step run after a tool is installed. Either Command, a Go template
+// run by the shell, or Action, one of the built-in Hook* actions, is set.
+// Command and Args are given .Name, .Version, .Path (the installed binary),
+// .BinDir, .Home and, for completions, .Shell.
+//
+// OptIn hooks change the system beyond ds, such as trusting a new root CA,
+// and only run for tools listed in the get.opt_in_hooks conf value.
+type Hook struct {
+	Command string `yaml:"command,omitempty"`
+	Action  string `yaml:"action,omitempty"`
+	Args    string `yaml:"args,omitempty"`
+	OptIn   bool   `yaml:"opt_in,omitempty"`
+}
+
+// optedIn reports whether the get.opt_in_hooks conf value lists the tool.
+func optedIn(tool *Tool) bool {
+	out := confString(".get.opt_in_hooks")
+	if out == "" {
+		return false
+	}
+	var names []string
+	err := yaml.Unmarshal([]byte(out), &names)
+	if err != nil {
+		log.Printf("Ignoring conf get.opt_in_hooks: %s\n", err)
+		return false
+	}
+	return contains(names, tool.Name)
+}
+
+// hookData returns the values available to a tool's hook templates.
+func hookData(tool *Tool, path, version string) map[string]string {
+	return map[string]string{
+		"Name":    tool.Name,
+		"Version": version,
+		"Path":    path,
+		"BinDir":  filepath.Dir(path),
+		"Home":    os.Getenv("HOME"),
+	}
+}
+
+// RunPostInstall runs the tool's PostInstall hooks in order against the
+// binary installed at path, stopping at the first failure.
+func RunPostInstall(tool *Tool, path, version string) error {
+	for i, hook := range tool.PostInstall {
+		data := hookData(tool, path, version)
+		var err error
+		switch {
+		case hook.OptIn && !optedIn(tool):
+			log.Printf("Skipping an opt-in hook, add %s to get.opt_in_hooks to run it\n", tool.Name)
+		case hook.Command != "":
+			err = runHookCommand(tool, i, hook, data)
+		case hook.Action == HookCompletion:
+			err = installCompletions(tool, i, hook, data)
+		case hook.Action == HookMkdir:
+			var dir string
+			dir, err = renderTemplate(fmt.Sprintf("%s_hook%d", tool.Name, i), hook.Args, data)
+			if err == nil {
+				log.Printf("Creating %q\n", dir)
+				err = mkdirp(dir)
+			}
+		case hook.Action == HookDockerPlugin:
+			err = installDockerPlugin(tool, hook, path)
+		default:
+			err = fmt.Errorf("unknown hook action %q", hook.Action)
+		}
+		if err != nil {
+			return fmt.Errorf("%s post-install: %w", tool.Name, err)
+		}
+	}
+	return nil
+}
+
+func runHookCommand(tool *Tool, i int, hook Hook, data map[string]string) error {
+	cmd, err := renderTemplate(fmt.Sprintf("%s_hook%d", tool.Name, i), hook.Command, data)
+	if err != nil {
+		return err
+	}
+	log.Printf("Running %q\n", cmd)
+	c := exec.Command("sh", "-c", cmd)
+	if runtime.GOOS == "windows" {
+		c = exec.Command("cmd", "/C", cmd)
+	}
+	// The output goes to stderr with the rest of the progress so that
+	// stdout only carries the results, which may be json or yaml.
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stderr
+	c.Stderr = os.Stderr
+	return c.Run()
+}
+
+// completionFile returns the conventional completion file name for shell.
+func completionFile(name, shell string) string {
+	switch shell {
+	case "zsh":
+		return "_" + name
+	case "fish":
+		return name + ".fish"
+	}
+	return name
+}
+
+// CompletionsDir returns the directory completions for shell are written
+// to.
+func CompletionsDir(shell string) string {
+	return filepath.Join(os.Getenv("HOME"), completionsPath, shell)
+}
+
+func installCompletions(tool *Tool, i int, hook Hook, data map[string]string) error {
+	for _, shell := range Shells {
+		data["Shell"] = shell
+		args, err := renderTemplate(fmt.Sprintf("%s_hook%d", tool.Name, i), hook.Args, data)
+		if err != nil {
+			return err
+		}
+		cmd := exec.Command(data["Path"], Z.ArgsFrom(args)...)
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return fmt.Errorf("generating %s completion: %w", shell, err)
+		}
+		dir := CompletionsDir(shell)
+		err = mkdirp(dir)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(dir, completionFile(tool.Name, shell))
+		err = os.WriteFile(file, bytes.TrimLeft(out, "\n"), 0600)
+		if err != nil {
+			return err
+		}
+		log.Printf("Wrote %s completion to %q\n", shell, file)
+	}
+	return nil
+}
+
+func installDockerPlugin(tool *Tool, hook Hook, path string) error {
+	name := hook.Args
+	if name == "" {
+		name = tool.Name
+	}
+	dir := filepath.Join(os.Getenv("HOME"), dockerPluginDir)
+	err := mkdirp(dir)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(dir, name)
+	_, err = CopyFile(path, dst, 0700)
+	if err != nil {
+		return err
+	}
+	log.Printf("Installed docker plugin %q\n", dst)
+	return nil
+}
@@ -0,0 +1,149 @@
+package get
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeBinary writes a shell script standing in for an installed tool that
+// prints its arguments.
+func fakeBinary(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are run with sh")
+	}
+	path := filepath.Join(t.TempDir(), "tool")
+	err := os.WriteFile(path, []byte("#!/bin/sh\necho \"completion for $*\"\n"), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// captureOutput returns what f writes to stdout and stderr.
+func captureOutput(t *testing.T, f func()) (string, string) {
+	stdout, stderr := os.Stdout, os.Stderr
+	defer func() { os.Stdout, os.Stderr = stdout, stderr }()
+	var out [2]string
+	var files [2]*os.File
+	for i, name := range []string{"stdout", "stderr"} {
+		file, err := os.Create(filepath.Join(t.TempDir(), name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer file.Close()
+		files[i] = file
+	}
+	os.Stdout, os.Stderr = files[0], files[1]
+	f()
+	for i, file := range files {
+		file.Seek(0, io.SeekStart)
+		b, _ := io.ReadAll(file)
+		out[i] = string(b)
+	}
+	return out[0], out[1]
+}
+
+func TestRunPostInstall(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := fakeBinary(t)
+
+	tt := []struct {
+		name  string
+		hooks []Hook
+		opts  options
+		files map[string]string
+		err   string
+	}{
+		{
+			name:  "command",
+			hooks: []Hook{{Command: "echo {{.Name}} {{.Version}} > {{.Home}}/command.txt"}},
+			files: map[string]string{"command.txt": "tool v1.0.0\n"},
+		},
+		{
+			name:  "completion",
+			hooks: []Hook{{Action: HookCompletion, Args: "completion {{.Shell}}"}},
+			files: map[string]string{
+				".ds/share/completions/bash/tool":      "completion for completion bash\n",
+				".ds/share/completions/zsh/_tool":      "completion for completion zsh\n",
+				".ds/share/completions/fish/tool.fish": "completion for completion fish\n",
+			},
+		},
+		{
+			name:  "docker plugin",
+			hooks: []Hook{{Action: HookDockerPlugin, Args: "docker-tool"}},
+			files: map[string]string{".docker/cli-plugins/docker-tool": "#!/bin/sh\necho \"completion for $*\"\n"},
+		},
+		{
+			name:  "mkdir",
+			hooks: []Hook{{Action: HookMkdir, Args: "{{.Home}}/.config/{{.Name}}"}},
+		},
+		{
+			name:  "unknown action",
+			hooks: []Hook{{Action: "reboot"}},
+			err:   `unknown hook action "reboot"`,
+		},
+		{
+			name:  "opt in",
+			hooks: []Hook{{Command: "echo trusted > {{.Home}}/opt-in.txt", OptIn: true}},
+		},
+		{
+			name:  "no hooks",
+			hooks: []Hook{{Command: "echo ran > {{.Home}}/no-hooks.txt"}},
+			opts:  options{NoHooks: true},
+		},
+	}
+
+	for _, tc := range tt {
+		tool := &Tool{Name: "tool", PostInstall: tc.hooks}
+		tc.opts.apply(tool)
+		err := RunPostInstall(tool, path, "v1.0.0")
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("%s: got error %v, want %q", tc.name, err, tc.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		for name, want := range tc.files {
+			b, err := os.ReadFile(filepath.Join(home, name))
+			if err != nil || string(b) != want {
+				t.Errorf("%s: %s is %q, %v, want %q", tc.name, name, b, err, want)
+			}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(home, ".config", "tool")); err != nil {
+		t.Errorf("mkdir: %v", err)
+	}
+	for _, name := range []string{"opt-in.txt", "no-hooks.txt"} {
+		if _, err := os.Stat(filepath.Join(home, name)); err == nil {
+			t.Errorf("%s: hook ran", name)
+		}
+	}
+}
+
+func TestHookOutput(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tool := &Tool{Name: "tool", PostInstall: []Hook{{Command: "echo hook output"}}}
+	var err error
+	stdout, stderr := captureOutput(t, func() {
+		err = RunPostInstall(tool, fakeBinary(t), "v1.0.0")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stdout != "" {
+		t.Errorf("hook wrote %q to stdout", stdout)
+	}
+	if !strings.Contains(stderr, "hook output") {
+		t.Errorf("hook output missing from stderr %q", stderr)
+	}
+}
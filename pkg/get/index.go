@@ -0,0 +1,376 @@
+package get
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/web"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"gopkg.in/yaml.v3"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IndexVersion is the version of the index format this ds understands.
+const IndexVersion = 1
+
+// maxIndexSize limits the size of a downloaded index.
+const maxIndexSize = 4 << 20
+
+// Index is a signed document of tool definitions published at a URL. It is
+// YAML or JSON with the same fields as Tool. Its detached signature is at
+// the same URL with ".sig" appended and holds the base64 encoded ed25519
+// signature of the exact index bytes.
+type Index struct {
+	// Version is the format version, IndexVersion.
+	Version int `yaml:"version"`
+
+	// Serial must increase with every publication. An index with a lower
+	// serial than the cached one is refused so it cannot be rolled back.
+	Serial int64 `yaml:"serial"`
+
+	Tools []Tool `yaml:"tools"`
+}
+
+// IndexConf is the get.index conf value listing the index URLs ds
+// subscribes to and the public keys, base64 ed25519 or PEM, their
+// signatures are checked against.
+//
+//	get:
+//	  index:
+//	    urls:
+//	      - https://example.com/ds/index.yaml
+//	    keys:
+//	      - MCowBQYDK2VwAyEA...
+type IndexConf struct {
+	URLs []string `yaml:"urls"`
+	Keys []string `yaml:"keys"`
+}
+
+// LoadIndexConf reads the get.index conf value. No conf means no indexes.
+func LoadIndexConf() (IndexConf, error) {
+	var c IndexConf
+	if Z.Conf == nil {
+		return c, nil
+	}
+	out, err := Z.Conf.Query(".get.index")
+	if err != nil {
+		return c, nil
+	}
+	out = strings.TrimSpace(out)
+	if out == "" || out == "null" {
+		return c, nil
+	}
+	err = yaml.Unmarshal([]byte(out), &c)
+	if err != nil {
+		return c, fmt.Errorf("conf get.index: %w", err)
+	}
+	return c, nil
+}
+
+// indexCacheFile returns where the index at url is cached.
+func indexCacheFile(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(CacheDir(), "index", hex.EncodeToString(sum[:8])+".yaml")
+}
+
+// parsePublicKey accepts a base64 encoded raw ed25519 key or a PEM or base64
+// encoded PKIX one.
+func parsePublicKey(key string) (ed25519.PublicKey, error) {
+	key = strings.TrimSpace(key)
+	var der []byte
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		der = block.Bytes
+	} else {
+		raw, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key: %w", err)
+		}
+		if len(raw) == ed25519.PublicKeySize {
+			return ed25519.PublicKey(raw), nil
+		}
+		der = raw
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	k, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("public key is not an ed25519 key")
+	}
+	return k, nil
+}
+
+// verifyIndex checks sig, the contents of a .sig file, is a signature of
+// data by one of keys. Keys that cannot be parsed are logged and skipped so
+// one bad key does not stop the others from being used.
+func verifyIndex(data, sig []byte, keys []string) error {
+	if len(keys) == 0 {
+		return errors.New("no index public keys are configured")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	valid := 0
+	for i, key := range keys {
+		pub, err := parsePublicKey(key)
+		if err != nil {
+			log.Printf("Skipping index key %d: %s\n", i+1, err)
+			continue
+		}
+		valid++
+		if ed25519.Verify(pub, data, raw) {
+			return nil
+		}
+	}
+	if valid == 0 {
+		return errors.New("none of the configured index public keys could be parsed")
+	}
+	return errors.New("signature does not match any configured key")
+}
+
+// parseIndex verifies and decodes an index.
+func parseIndex(data, sig []byte, keys []string) (*Index, error) {
+	err := verifyIndex(data, sig, keys)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{}
+	err = yaml.Unmarshal(data, idx)
+	if err != nil {
+		return nil, err
+	}
+	if idx.Version != IndexVersion {
+		return nil, fmt.Errorf("unsupported index version %d, this ds supports version %d", idx.Version, IndexVersion)
+	}
+	for _, t := range idx.Tools {
+		if t.Name == "" || t.Owner == "" || t.Repo == "" {
+			return nil, fmt.Errorf("index tool %q must have a name, owner and repo", t.Name)
+		}
+	}
+	return idx, nil
+}
+
+// readURL returns the contents of an http, https or file URL.
+func readURL(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "file" {
+		return os.ReadFile(filepath.FromSlash(u.Path))
+	}
+	res, err := web.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code fetching %s: %d", rawURL, res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxIndexSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxIndexSize {
+		return nil, fmt.Errorf("index %s is larger than %d bytes", rawURL, maxIndexSize)
+	}
+	return data, nil
+}
+
+// lockIndex locks the cached copy of the index at url so its signature is
+// never read while the two are being replaced.
+func lockIndex(url string) (*filelock.Lock, error) {
+	return filelock.Acquire(indexCacheFile(url) + ".lock")
+}
+
+// loadCachedIndex returns the cached copy of the index at url, verified
+// again so a modified cache is never used.
+func loadCachedIndex(url string, keys []string) (*Index, error) {
+	file := indexCacheFile(url)
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	l, err := lockIndex(url)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+	return readCachedIndex(file, keys)
+}
+
+// readCachedIndex reads and verifies the cached index in file. The caller
+// holds the index lock.
+func readCachedIndex(file string, keys []string) (*Index, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := os.ReadFile(file + ".sig")
+	if err != nil {
+		return nil, err
+	}
+	return parseIndex(data, sig, keys)
+}
+
+// UpdateIndex fetches and verifies the index at url and replaces the
+// cached copy.
+func UpdateIndex(url string, keys []string) (*Index, error) {
+	data, err := readURL(url)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := readURL(url + ".sig")
+	if err != nil {
+		return nil, err
+	}
+	idx, err := parseIndex(data, sig, keys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+
+	file := indexCacheFile(url)
+	l, err := lockIndex(url)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+	if cached, err := readCachedIndex(file, keys); err == nil && idx.Serial < cached.Serial {
+		return nil, fmt.Errorf("%s: serial %d is older than the cached serial %d", url, idx.Serial, cached.Serial)
+	}
+	err = filelock.WriteFile(file, data, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return idx, filelock.WriteFile(file+".sig", sig, 0600)
+}
+
+// Registry returns the built in tools merged with those of every cached
+// index, in the order the indexes are configured. A tool in an index
+// replaces a tool of the same name. Indexes that cannot be loaded are
+// skipped with a warning.
+func Registry() Tools {
+	tools := MakeTools()
+	c, err := LoadIndexConf()
+	if err != nil {
+		log.Printf("Skipping tool indexes: %s\n", err)
+		return tools
+	}
+	for _, url := range c.URLs {
+		idx, err := loadCachedIndex(url, c.Keys)
+		if os.IsNotExist(err) {
+			log.Printf("Index %s has not been fetched, run ds get update-index\n", url)
+			continue
+		}
+		if err != nil {
+			log.Printf("Skipping index %s: %s\n", url, err)
+			continue
+		}
+		tools = mergeTools(tools, idx.Tools)
+	}
+	return tools
+}
+
+// mergeTools adds extra to tools, replacing tools with the same name.
+func mergeTools(tools Tools, extra []Tool) Tools {
+	for _, t := range extra {
+		replaced := false
+		for i := range tools {
+			if tools[i].Name == t.Name {
+				tools[i] = t
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			tools = append(tools, t)
+		}
+	}
+	return tools
+}
+
+var updateIndex = &Z.Cmd{
+	Name:     `update-index`,
+	Summary:  `fetch the configured tool indexes [requires internet]`,
+	Usage:    `[--output FORMAT]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *update-index* command fetches every tool index listed in the
+		*get.index.urls* conf value, checks its ed25519 signature against the
+		*get.index.keys* and caches it. Tools in the indexes are then
+		available to *ds get* alongside the built in ones, replacing any with
+		the same name, without rebuilding ds.
+
+		An index is a YAML or JSON document with a format *version*, an
+		increasing *serial* and a list of *tools* using the same fields as the
+		built in tools. Its signature is published next to it with *.sig*
+		appended, for example with openssl:
+
+		    openssl pkeyutl -sign -inkey key.pem -rawin -in index.yaml | base64 -w0 > index.yaml.sig
+		    openssl pkey -in key.pem -pubout   # the public key for get.index.keys
+
+		Index URLs may use https, http or file.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		c, err := LoadIndexConf()
+		if err != nil {
+			return err
+		}
+		if len(c.URLs) == 0 {
+			return errors.New("no tool indexes are configured, add get.index.urls with ds conf edit")
+		}
+
+		type result struct {
+			URL    string `json:"url" yaml:"url"`
+			Serial int64  `json:"serial" yaml:"serial"`
+			Tools  int    `json:"tools" yaml:"tools"`
+			Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+		}
+		var results []result
+		v := view{Header: []string{"Index", "Serial", "Tools", "Status"}}
+		failed := 0
+		for _, url := range c.URLs {
+			r := result{URL: url}
+			idx, err := UpdateIndex(url, c.Keys)
+			status := "updated"
+			if err != nil {
+				r.Error = err.Error()
+				status = err.Error()
+				failed++
+			} else {
+				r.Serial, r.Tools = idx.Serial, len(idx.Tools)
+			}
+			results = append(results, r)
+			v.Rows = append(v.Rows, []string{url, fmt.Sprint(r.Serial), fmt.Sprint(r.Tools), status})
+		}
+		v.Value = results
+		err = v.write(os.Stdout, opts.Output)
+		if err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d indexes failed to update", failed, len(c.URLs))
+		}
+		return nil
+	},
+}
@@ -0,0 +1,151 @@
+package get
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeIndex(t *testing.T, file string, priv ed25519.PrivateKey, body string) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(body)))
+	if err := os.WriteFile(file+".sig", []byte(sig+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpdateIndex(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+
+	file := filepath.Join(t.TempDir(), "index.yaml")
+	url := "file://" + filepath.ToSlash(file)
+	index := `version: 1
+serial: 2
+tools:
+  - name: k9s
+    owner: derailed
+    repo: k9s
+    version: "~0.26"
+    binary_template: k9s_{{.OS}}_{{.Arch}}.tar.gz
+  - name: internal
+    owner: acme
+    repo: internal-cli
+    verify:
+      checksums: checksums.txt
+`
+	for _, keys := range [][]string{
+		{base64.StdEncoding.EncodeToString(pub)},
+		{pemKey},
+		{base64.StdEncoding.EncodeToString(der)},
+	} {
+		writeIndex(t, file, priv, index)
+		idx, err := UpdateIndex(url, keys)
+		if err != nil {
+			t.Fatalf("UpdateIndex with key %q: %v", keys[0][:10], err)
+		}
+		if len(idx.Tools) != 2 || idx.Tools[0].Version != "~0.26" || idx.Tools[1].Verify.Checksums != "checksums.txt" {
+			t.Errorf("tools = %+v", idx.Tools)
+		}
+	}
+	keys := []string{pemKey}
+
+	writeIndex(t, file, other, index)
+	if _, err := UpdateIndex(url, keys); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("index signed by another key: %v", err)
+	}
+
+	writeIndex(t, file, priv, strings.Replace(index, "serial: 2", "serial: 1", 1))
+	if _, err := UpdateIndex(url, keys); err == nil || !strings.Contains(err.Error(), "older") {
+		t.Errorf("rolled back index: %v", err)
+	}
+
+	writeIndex(t, file, priv, strings.Replace(index, "version: 1", "version: 2", 1))
+	if _, err := UpdateIndex(url, keys); err == nil || !strings.Contains(err.Error(), "unsupported") {
+		t.Errorf("future index version: %v", err)
+	}
+
+	cache := indexCacheFile(url)
+	if err := os.WriteFile(cache, []byte(strings.Replace(index, "acme", "evil", 1)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCachedIndex(url, keys); err == nil {
+		t.Error("modified cached index was accepted")
+	}
+}
+
+func TestReadURLTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(bytes.Repeat([]byte("a"), maxIndexSize+1))
+	}))
+	defer srv.Close()
+
+	_, err := readURL(srv.URL + "/index.yaml")
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("got %v, want a size limit error", err)
+	}
+}
+
+func TestVerifyIndex(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	good := base64.StdEncoding.EncodeToString(pub)
+	other := base64.StdEncoding.EncodeToString(otherPub)
+	bad := "not a key"
+	data := []byte("version: 1\n")
+	sig := []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, data)))
+
+	tt := []struct {
+		name string
+		keys []string
+		err  string
+	}{
+		{"good key", []string{good}, ""},
+		{"bad key then good key", []string{bad, good}, ""},
+		{"other key then good key", []string{other, good}, ""},
+		{"good key then bad key", []string{good, bad}, ""},
+		{"only bad keys", []string{bad, bad}, "could be parsed"},
+		{"bad key then other key", []string{bad, other}, "does not match"},
+		{"no keys", nil, "no index public keys"},
+	}
+	for _, tc := range tt {
+		err := verifyIndex(data, sig, tc.keys)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("%s: got %v, want an error containing %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func TestMergeTools(t *testing.T) {
+	tools := mergeTools(Tools{{Name: "a", Repo: "old"}, {Name: "b"}}, []Tool{{Name: "a", Repo: "new"}, {Name: "c"}})
+	if len(tools) != 3 || tools[0].Repo != "new" || tools[2].Name != "c" {
+		t.Errorf("mergeTools = %+v", tools)
+	}
+}
@@ -0,0 +1,220 @@
+package get
+
+import (
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"os"
+	"strings"
+	"time"
+)
+
+// toolInfo is the detail shown by the info command.
+type toolInfo struct {
+	Name        string     `json:"name" yaml:"name"`
+	Description string     `json:"description" yaml:"description"`
+	Repo        string     `json:"repo" yaml:"repo"`
+	Version     string     `json:"version" yaml:"version"`
+	Channel     string     `json:"channel" yaml:"channel"`
+	Verified    bool       `json:"verified" yaml:"verified"`
+	Installed   *Installed `json:"installed,omitempty" yaml:"installed,omitempty"`
+}
+
+var info = &Z.Cmd{
+	Name:     `info`,
+	Summary:  `show the details of a tool`,
+	Usage:    `[--output FORMAT] TOOL`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *info* command shows where a tool is downloaded from, the version
+		ds will install and, if it has been installed, the installed version.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) != 1 {
+			return caller.UsageError()
+		}
+		t, err := getTool(args[0], Registry())
+		if err != nil {
+			return err
+		}
+		st, err := LoadState()
+		if err != nil {
+			return err
+		}
+
+		ti := toolInfo{
+			Name:        t.Name,
+			Description: t.Description,
+			Repo:        t.Owner + "/" + t.Repo,
+			Version:     t.Version,
+			Channel:     t.Channel,
+			Verified:    t.Verify != nil,
+		}
+		if ti.Version == "" {
+			ti.Version = "latest"
+		}
+		if ti.Channel == "" {
+			ti.Channel = ChannelStable
+		}
+		rows := [][]string{
+			{"Name", ti.Name},
+			{"Description", ti.Description},
+			{"Repo", "https://github.com/" + ti.Repo},
+			{"Version", ti.Version},
+			{"Channel", ti.Channel},
+			{"Verified", fmt.Sprint(ti.Verified)},
+		}
+		if in, ok := st.Tools[t.Name]; ok {
+			ti.Installed = &in
+			rows = append(rows,
+				[]string{"Installed", in.Version},
+				[]string{"Path", in.Path},
+				[]string{"SHA256", in.SHA256},
+			)
+		}
+		return view{Header: []string{"Field", "Value"}, Rows: rows, Value: ti}.write(os.Stdout, opts.Output)
+	},
+}
+
+var installed = &Z.Cmd{
+	Name:     `installed`,
+	Summary:  `list the tools installed by ds`,
+	Usage:    `[--output FORMAT]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		st, err := LoadState()
+		if err != nil {
+			return err
+		}
+		list := st.List()
+		v := view{
+			Header:  []string{"Tool", "Version", "Path", "Installed"},
+			Value:   list,
+			Caption: fmt.Sprintf("%d tools are installed.\n", len(list)),
+		}
+		if list == nil {
+			v.Value = []Installed{}
+		}
+		for _, in := range list {
+			v.Rows = append(v.Rows, []string{in.Name, in.Version, in.Path, in.InstalledAt.Local().Format(time.RFC822)})
+		}
+		return v.write(os.Stdout, opts.Output)
+	},
+}
+
+// outdatedTool is an installed tool compared with its latest release.
+type outdatedTool struct {
+	Name      string `json:"name" yaml:"name"`
+	Installed string `json:"installed" yaml:"installed"`
+	Latest    string `json:"latest" yaml:"latest"`
+	Outdated  bool   `json:"outdated" yaml:"outdated"`
+	Error     string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// newer reports whether latest is a newer version than current. Tags that
+// cannot be parsed as versions are simply compared for equality.
+func newer(current, latest string) bool {
+	cv, cok := parseSemver(current)
+	lv, lok := parseSemver(latest)
+	if cok && lok {
+		return lv.compare(cv) > 0
+	}
+	return current != latest
+}
+
+var outdated = &Z.Cmd{
+	Name:     `outdated`,
+	Summary:  `list installed tools with a newer release [requires internet]`,
+	Usage:    `[--pre] [--output FORMAT] [TOOL...]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *outdated* command compares every tool installed by ds, or only
+		those named, against the release ds would install today.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--pre", "--output")
+		if err != nil {
+			return err
+		}
+		st, err := LoadState()
+		if err != nil {
+			return err
+		}
+		tools := Registry()
+
+		var list []outdatedTool
+		for _, in := range st.List() {
+			if len(args) > 0 && !contains(args, in.Name) {
+				continue
+			}
+			o := outdatedTool{Name: in.Name, Installed: in.Version}
+			latest, err := latestVersion(in.Name, tools, opts.Pre)
+			if err != nil {
+				o.Error = err.Error()
+			} else {
+				o.Latest = latest
+				o.Outdated = newer(in.Version, latest)
+			}
+			list = append(list, o)
+		}
+
+		v := view{Header: []string{"Tool", "Installed", "Latest", "Status"}, Value: list}
+		if list == nil {
+			v.Value = []outdatedTool{}
+		}
+		count := 0
+		for _, o := range list {
+			status := "up to date"
+			switch {
+			case o.Error != "":
+				status = o.Error
+			case o.Outdated:
+				status = "outdated"
+				count++
+			}
+			v.Rows = append(v.Rows, []string{o.Name, o.Installed, o.Latest, status})
+		}
+		v.Caption = fmt.Sprintf("%d of %d tools are outdated.\n", count, len(list))
+		return v.write(os.Stdout, opts.Output)
+	},
+}
+
+// latestVersion returns the tag ds would install for the named tool.
+func latestVersion(name string, tools Tools, pre bool) (string, error) {
+	t, err := getTool(name, tools)
+	if err != nil {
+		return "", err
+	}
+	releases, err := FindGithubRelease(t.Owner, t.Repo)
+	if err != nil {
+		return "", err
+	}
+	version := t.Version
+	if version == "" {
+		version = "latest"
+	}
+	release, err := selectRelease(releases, version, pre || t.Channel == ChannelPre)
+	if err != nil {
+		return "", err
+	}
+	return release.TagName, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if strings.EqualFold(l, s) {
+			return true
+		}
+	}
+	return false
+}
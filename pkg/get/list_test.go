@@ -0,0 +1,134 @@
+package get
+
+import (
+	"bytes"
+	"encoding/json"
+	Z "github.com/rwxrob/bonzai/z"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListTools(t *testing.T) {
+	tools := Tools{
+		{Name: "jq", Owner: "stedolan", Repo: "jq", Description: "JSON processor", Tags: []string{"data"}},
+		{Name: "k9s", Owner: "derailed", Repo: "k9s", Description: "Kubernetes TUI", Tags: []string{"kubernetes", "tui"}},
+	}
+
+	tt := []struct {
+		name   string
+		tools  Tools
+		format string
+		want   string
+	}{
+		{"tsv", tools, OutputTSV, "Tool\tDescription\tTags\njq\tJSON processor\tdata\nk9s\tKubernetes TUI\tkubernetes, tui\n"},
+		{"yaml", tools[:1], OutputYAML, "- name: jq\n  description: JSON processor\n  repo: stedolan/jq\n  tags:\n    - data\n"},
+		{"empty json", nil, OutputJSON, "[]\n"},
+		{"empty yaml", nil, OutputYAML, "[]\n"},
+	}
+	for _, tc := range tt {
+		var buf bytes.Buffer
+		err := ListTools(&buf, tc.tools, tc.format)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, buf.String(), tc.want)
+		}
+	}
+
+	var buf bytes.Buffer
+	err := ListTools(&buf, tools, OutputJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []toolSummary
+	err = json.Unmarshal(buf.Bytes(), &list)
+	if err != nil || len(list) != 2 || list[1].Repo != "derailed/k9s" {
+		t.Errorf("json: got %+v, %v", list, err)
+	}
+}
+
+// runCmd calls the command with args and returns its stdout.
+func runCmd(t *testing.T, cmd *Z.Cmd, args ...string) string {
+	var err error
+	out, _ := captureOutput(t, func() { err = cmd.Call(cmd, args...) })
+	if err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return out
+}
+
+func TestListCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	tt := []struct {
+		name string
+		cmd  string
+		args []string
+		want string
+	}{
+		{"installed json", "installed", []string{"--output", "json"}, "[]\n"},
+		{"installed yaml", "installed", []string{"--output", "yaml"}, "[]\n"},
+		{"installed tsv", "installed", []string{"--output", "tsv"}, "Tool\tVersion\tPath\tInstalled\n"},
+		{"outdated json", "outdated", []string{"--output", "json"}, "[]\n"},
+		{"outdated yaml", "outdated", []string{"--output", "yaml"}, "[]\n"},
+	}
+	cmds := map[string]*Z.Cmd{"installed": installed, "outdated": outdated, "info": info}
+	for _, tc := range tt {
+		if got := runCmd(t, cmds[tc.cmd], tc.args...); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	releaseCache.Lock()
+	releaseCache.repos["https://api.github.com/repos/derailed/k9s/releases?per_page=100"] = []*GithubAPIReleasesResponse{
+		{TagName: "v0.28.0-rc.1", Prerelease: true},
+		{TagName: "v0.27.4"},
+	}
+	releaseCache.repos["https://api.github.com/repos/stedolan/jq/releases?per_page=100"] = []*GithubAPIReleasesResponse{
+		{TagName: "jq-1.6"},
+	}
+	releaseCache.Unlock()
+	installedAt := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	for _, in := range []Installed{
+		{Name: "k9s", Version: "v0.27.3", Path: "/bin/k9s", SHA256: "abc", InstalledAt: installedAt},
+		{Name: "jq", Version: "jq-1.6", Path: "/bin/jq", SHA256: "def", InstalledAt: installedAt},
+	} {
+		if err := recordInstall(in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var list []Installed
+	err := json.Unmarshal([]byte(runCmd(t, cmds["installed"], "--output", "json")), &list)
+	if err != nil || len(list) != 2 || list[0].Name != "jq" || list[1].Version != "v0.27.3" {
+		t.Errorf("installed: got %+v, %v", list, err)
+	}
+
+	var outdatedList []outdatedTool
+	err = yaml.Unmarshal([]byte(runCmd(t, cmds["outdated"], "--output", "yaml")), &outdatedList)
+	want := []outdatedTool{
+		{Name: "jq", Installed: "jq-1.6", Latest: "jq-1.6"},
+		{Name: "k9s", Installed: "v0.27.3", Latest: "v0.27.4", Outdated: true},
+	}
+	if err != nil || len(outdatedList) != 2 || outdatedList[0] != want[0] || outdatedList[1] != want[1] {
+		t.Errorf("outdated: got %+v, %v, want %+v", outdatedList, err, want)
+	}
+	out := runCmd(t, cmds["outdated"], "--output", "tsv", "--pre", "k9s")
+	if out != "Tool\tInstalled\tLatest\tStatus\nk9s\tv0.27.3\tv0.28.0-rc.1\toutdated\n" {
+		t.Errorf("outdated --pre: got %q", out)
+	}
+
+	var ti toolInfo
+	err = json.Unmarshal([]byte(runCmd(t, cmds["info"], "--output", "json", "k9s")), &ti)
+	if err != nil || ti.Repo != "derailed/k9s" || ti.Channel != ChannelStable || ti.Installed == nil || ti.Installed.Version != "v0.27.3" {
+		t.Errorf("info: got %+v, %v", ti, err)
+	}
+	out = runCmd(t, cmds["info"], "--output", "tsv", "hey")
+	if !strings.Contains(out, "Version\tlatest\n") || strings.Contains(out, "Installed") {
+		t.Errorf("info of a tool that is not installed: got %q", out)
+	}
+}
@@ -0,0 +1,341 @@
+package get
+
+import (
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"gopkg.in/yaml.v3"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// LockFile is the name of the project lock file. The nearest one in the
+// current directory or its parents marks the project directory.
+var LockFile = "ds.lock"
+
+// Lock pins the exact release of each tool a project uses along with the
+// download URL and SHA-256 sum of its asset on every platform.
+type Lock struct {
+	Tools []LockedTool `json:"tools" yaml:"tools"`
+}
+
+// LockedTool is a tool pinned to a single release.
+type LockedTool struct {
+	Name    string                 `json:"name" yaml:"name"`
+	Version string                 `json:"version" yaml:"version"`
+	Assets  map[string]LockedAsset `json:"assets" yaml:"assets"`
+}
+
+// LockedAsset is the asset of a locked tool for one platform, keyed by
+// "os/arch" using Go's names.
+type LockedAsset struct {
+	URL    string `json:"url" yaml:"url"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// LoadLock reads a lock file. A missing file is an empty lock.
+func LoadLock(file string) (*Lock, error) {
+	l := &Lock{}
+	buf, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(buf, l)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return l, nil
+}
+
+// Save writes the lock file with the tools sorted by name.
+func (l *Lock) Save(file string) error {
+	sort.Slice(l.Tools, func(i, j int) bool { return l.Tools[i].Name < l.Tools[j].Name })
+	buf, err := yaml.Marshal(l)
+	if err != nil {
+		return err
+	}
+	header := "# Generated by ds get lock, do not edit. Install with ds get sync.\n"
+	return filelock.WriteFile(file, append([]byte(header), buf...), 0644)
+}
+
+// lockLockFile locks the lock file so concurrent runs of ds get lock do not
+// lose each other's tools. The lock is kept in the .ds directory next to
+// it rather than in the project itself.
+func lockLockFile(file string) (*filelock.Lock, error) {
+	return filelock.Acquire(filepath.Join(filepath.Dir(file), locksFilePath, filepath.Base(file)+".lock"))
+}
+
+// set adds or replaces the locked tool.
+func (l *Lock) set(t LockedTool) {
+	for i := range l.Tools {
+		if l.Tools[i].Name == t.Name {
+			l.Tools[i] = t
+			return
+		}
+	}
+	l.Tools = append(l.Tools, t)
+}
+
+// platforms returns the platforms already in the lock file.
+func (l *Lock) platforms() []Platform {
+	seen := map[string]bool{}
+	var list []Platform
+	for _, t := range l.Tools {
+		for key := range t.Assets {
+			if p, err := parsePlatform(key); err == nil && !seen[key] {
+				seen[key] = true
+				list = append(list, p)
+			}
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].String() < list[j].String() })
+	return list
+}
+
+func parsePlatform(s string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(s, "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("invalid platform %q, must be os/arch such as linux/amd64", s)
+	}
+	return Platform{goos, goarch}, nil
+}
+
+// LockTool resolves version of the tool and records the asset for each
+// platform. Every asset is downloaded so its sum can be recorded, and is
+// verified first when the tool has Verify assets. Platforms the release has
+// no asset for are skipped.
+func LockTool(tool *Tool, version string, platforms []Platform) (LockedTool, error) {
+	lt := LockedTool{Name: tool.Name, Assets: map[string]LockedAsset{}}
+	release, err := resolveRelease(tool, version)
+	if err != nil {
+		return lt, err
+	}
+	lt.Version = release.TagName
+
+	for _, p := range platforms {
+		arch, opSystem := clientArch(p.OS, p.Arch)
+		asset, err := releaseAsset(tool, release, arch, opSystem)
+		if err != nil {
+			log.Printf("Skipping %s on %s: %s\n", tool.Name, p, err)
+			continue
+		}
+		file, err := downloadFile(asset.URL)
+		if err != nil {
+			return lt, err
+		}
+		err = VerifyAsset(tool, asset, file)
+		if err != nil {
+			os.Remove(file)
+			return lt, err
+		}
+		sum, err := sha256File(file)
+		os.Remove(file)
+		if err != nil {
+			return lt, err
+		}
+		lt.Assets[p.String()] = LockedAsset{URL: asset.URL, SHA256: sum}
+	}
+	if len(lt.Assets) == 0 {
+		return lt, fmt.Errorf("%s %s has no assets for any of the requested platforms", tool.Name, lt.Version)
+	}
+	return lt, nil
+}
+
+// SyncTool installs the locked tool for the current platform. It does
+// nothing when the locked asset is already installed and unmodified. The
+// returned status describes what was done.
+func SyncTool(tool *Tool, lt LockedTool, st *State) (string, error) {
+	key := runtime.GOOS + "/" + runtime.GOARCH
+	la, ok := lt.Assets[key]
+	if !ok {
+		return "", fmt.Errorf("%s has no %s asset for %s, add the platform with ds get lock --platform %s", LockFile, lt.Name, key, key)
+	}
+
+	if in, ok := st.Tools[lt.Name]; ok && in.URL == la.URL {
+		if sum, err := sha256File(in.Path); err == nil && sum == in.SHA256 {
+			return "up to date", nil
+		}
+	}
+
+	arch, opSystem := GetClientArch()
+	asset := &Asset{
+		Name:    path.Base(la.URL),
+		URL:     la.URL,
+		Version: lt.Version,
+		OS:      opSystem,
+		Arch:    arch,
+	}
+	_, err := installAsset(tool, asset, la.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return "installed", nil
+}
+
+var lock = &Z.Cmd{
+	Name:     `lock`,
+	Summary:  `pin tools for this project in ds.lock [requires internet]`,
+	Usage:    `[--platform OS/ARCH[,...]] [--pre] [TOOL[@VERSION]...]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *lock* command resolves each tool to an exact release and writes
+		its download URL and SHA-256 sum for every platform to the nearest
+		*ds.lock*, in the current directory or any parent, creating it in the
+		current directory if there is none. Commit the file and run *ds get sync* to
+		install exactly those releases on any machine.
+
+		Tools already in the lock file are kept. Naming a tool again updates
+		it and running *lock* without any tools updates every tool to the
+		newest release allowed by the registry. The platforms default to those
+		already in the lock file, or every supported platform for a new lock
+		file.
+
+		    ds get lock k9s@v0.27.4 jq
+		    ds get lock --platform linux/amd64,darwin/arm64 gh`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		defer tempdir.Cleanup()
+		opts, args, err := parseOptions(caller, args, "--platform", "--pre")
+		if err != nil {
+			return err
+		}
+		file := LockFile
+		if dir, ok := ProjectDir(); ok {
+			file = filepath.Join(dir, LockFile)
+		}
+		fl, err := lockLockFile(file)
+		if err != nil {
+			return err
+		}
+		defer fl.Release()
+		l, err := LoadLock(file)
+		if err != nil {
+			return err
+		}
+
+		platforms := l.platforms()
+		if len(opts.Platforms) > 0 {
+			platforms = nil
+			for _, s := range opts.Platforms {
+				p, err := parsePlatform(s)
+				if err != nil {
+					return err
+				}
+				platforms = append(platforms, p)
+			}
+		}
+		if len(platforms) == 0 {
+			platforms = Platforms
+		}
+
+		if len(args) == 0 {
+			for _, t := range l.Tools {
+				args = append(args, t.Name)
+			}
+		}
+		if len(args) == 0 {
+			return caller.UsageError()
+		}
+
+		tools := Registry()
+		for _, arg := range args {
+			name, version, _ := strings.Cut(arg, "@")
+			t, err := getTool(name, tools)
+			if err != nil {
+				return err
+			}
+			if version == "" {
+				version = t.Version
+			}
+			if version == "" {
+				version = "latest"
+			}
+			if opts.Pre {
+				t.Channel = ChannelPre
+			}
+			lt, err := LockTool(&t, version, platforms)
+			if err != nil {
+				return err
+			}
+			l.set(lt)
+			log.Printf("Locked %s %s\n", lt.Name, lt.Version)
+		}
+		return l.Save(file)
+	},
+}
+
+var syncLock = &Z.Cmd{
+	Name:     `sync`,
+	Summary:  `install the tools pinned in ds.lock [requires internet]`,
+	Usage:    `[--global] [--no-hooks] [--output FORMAT]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *sync* command installs every tool in the nearest *ds.lock*, in
+		the current directory or any parent, at the pinned release for the
+		current platform. Each download must match the SHA-256 sum in the
+		lock file, any difference is an error. Tools that are already
+		installed from the pinned asset are left alone.
+
+		Tools are installed into *.ds/bin* next to the lock file so each
+		project can use its own versions, see *ds exec*. Pass *--global* to
+		install them into *~/.ds/bin* instead.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		defer tempdir.Cleanup()
+		opts, args, err := parseOptions(caller, args, "--global", "--no-hooks", "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		dir, ok := ProjectDir()
+		if !ok {
+			return fmt.Errorf("no %s found, create one with ds get lock", LockFile)
+		}
+		l, err := LoadLock(filepath.Join(dir, LockFile))
+		if err != nil {
+			return err
+		}
+		if !opts.Global {
+			err = initProject(dir)
+			if err != nil {
+				return err
+			}
+		}
+		st, err := LoadState()
+		if err != nil {
+			return err
+		}
+
+		tools := Registry()
+		v := view{Header: []string{"Tool", "Version", "Status"}}
+		var synced []LockedTool
+		for _, lt := range l.Tools {
+			t, err := getTool(lt.Name, tools)
+			if err != nil {
+				return err
+			}
+			opts.apply(&t)
+			status, err := SyncTool(&t, lt, st)
+			if err != nil {
+				return err
+			}
+			v.Rows = append(v.Rows, []string{lt.Name, lt.Version, status})
+			synced = append(synced, lt)
+		}
+		v.Value = synced
+		if synced == nil {
+			v.Value = []LockedTool{}
+		}
+		return v.write(os.Stdout, opts.Output)
+	},
+}
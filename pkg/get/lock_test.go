@@ -0,0 +1,110 @@
+package get
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ds.lock")
+	l, err := LoadLock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Tools) != 0 {
+		t.Fatalf("missing lock file should be empty, got %v", l.Tools)
+	}
+
+	l.set(LockedTool{Name: "k9s", Version: "v0.27.3", Assets: map[string]LockedAsset{
+		"linux/amd64": {URL: "https://example.com/k9s_Linux_x86_64.tar.gz", SHA256: "aa"},
+	}})
+	l.set(LockedTool{Name: "jq", Version: "jq-1.6", Assets: map[string]LockedAsset{
+		"darwin/arm64": {URL: "https://example.com/jq-osx-amd64", SHA256: "bb"},
+	}})
+	l.set(LockedTool{Name: "k9s", Version: "v0.27.4", Assets: map[string]LockedAsset{
+		"linux/amd64": {URL: "https://example.com/k9s_Linux_x86_64.tar.gz", SHA256: "cc"},
+	}})
+	if err := l.Save(file); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadLock(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, l) {
+		t.Errorf("round trip = %+v, want %+v", got, l)
+	}
+	if len(got.Tools) != 2 || got.Tools[0].Name != "jq" || got.Tools[1].Version != "v0.27.4" {
+		t.Errorf("tools = %+v", got.Tools)
+	}
+	want := []Platform{{"darwin", "arm64"}, {"linux", "amd64"}}
+	if p := got.platforms(); !reflect.DeepEqual(p, want) {
+		t.Errorf("platforms = %v, want %v", p, want)
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	tt := []struct {
+		in   string
+		want Platform
+		err  bool
+	}{
+		{"linux/amd64", Platform{"linux", "amd64"}, false},
+		{"darwin/arm64", Platform{"darwin", "arm64"}, false},
+		{"linux", Platform{}, true},
+		{"/amd64", Platform{}, true},
+	}
+	for _, tc := range tt {
+		got, err := parsePlatform(tc.in)
+		if (err != nil) != tc.err || got != tc.want {
+			t.Errorf("parsePlatform(%q) = %v, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestSyncToolDrift(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "not the locked binary")
+	}))
+	defer srv.Close()
+
+	tool := &Tool{Name: "drift"}
+	key := runtime.GOOS + "/" + runtime.GOARCH
+	st := &State{Tools: map[string]Installed{}}
+
+	_, err := SyncTool(tool, LockedTool{Name: "drift", Version: "v1.0.0"}, st)
+	if err == nil || !strings.Contains(err.Error(), key) {
+		t.Errorf("missing platform error = %v", err)
+	}
+
+	lt := LockedTool{Name: "drift", Version: "v1.0.0", Assets: map[string]LockedAsset{
+		key: {URL: srv.URL + "/drift-bin", SHA256: strings.Repeat("0", 64)},
+	}}
+	_, err = SyncTool(tool, lt, st)
+	if err == nil || !strings.Contains(err.Error(), "does not match the lock file") {
+		t.Errorf("drift error = %v", err)
+	}
+
+	sum := sha256.Sum256([]byte("not the locked binary"))
+	lt.Assets[key] = LockedAsset{URL: lt.Assets[key].URL, SHA256: hex.EncodeToString(sum[:])}
+	for _, want := range []string{"installed", "up to date"} {
+		st, err := LoadState()
+		if err != nil {
+			t.Fatal(err)
+		}
+		status, err := SyncTool(tool, lt, st)
+		if err != nil || status != want {
+			t.Errorf("SyncTool = %q, %v, want %q", status, err, want)
+		}
+	}
+}
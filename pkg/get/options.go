@@ -0,0 +1,164 @@
+package get
+
+import (
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"strings"
+)
+
+// options holds the flags accepted by get and its subcommands. Bonzai does
+// not parse flags so they are pulled out of the arguments wherever they
+// appear and the remaining arguments are returned in order. Everything
+// after "--" is left untouched.
+type options struct {
+	// Pre includes prereleases when resolving the latest version.
+	Pre bool
+
+	// NoHooks skips the tool's PostInstall hooks.
+	NoHooks bool
+
+	// GoInstall builds tools from source with go install when their
+	// release has no asset for the platform.
+	GoInstall bool
+
+	// Output is the format listings and results are written in, one of
+	// Outputs.
+	Output string
+
+	// Global installs locked tools into the home directory rather than
+	// the project.
+	Global bool
+
+	// Platforms are the os/arch pairs written to a lock file.
+	Platforms []string
+
+	// Categories filter listings to tools with any of these tags.
+	Categories []string
+
+	// MaxAge and MaxSize override the clean budget from conf when set.
+	MaxAge  string
+	MaxSize string
+
+	// DryRun reports what clean would remove without removing it.
+	DryRun bool
+
+	// Version is the release self-update installs instead of the latest.
+	// Only self-update accepts --version, other commands take TOOL@VERSION.
+	Version string
+
+	// Check reports whether self-update would update ds without doing so.
+	Check bool
+
+	// Format is what export writes, one of ExportFormats.
+	Format string
+}
+
+// parseOptions separates flags from args. Only the flags the caller
+// accepts are allowed, any other is a usage error. Flags taking a value
+// accept both "--flag value" and "--flag=value".
+func parseOptions(caller *Z.Cmd, args []string, flags ...string) (options, []string, error) {
+	opts := options{Output: OutputTable}
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			rest = append(rest, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !contains(flags, name) {
+			// Only self-update takes --version, tools are pinned as
+			// TOOL@VERSION.
+			if name == "--version" {
+				return opts, nil, fmt.Errorf("unknown flag %s, pin a release with TOOL@VERSION, %w", name, caller.UsageError())
+			}
+			return opts, nil, fmt.Errorf("unknown flag %s, %w", name, caller.UsageError())
+		}
+		needValue := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+			if i+1 >= len(args) {
+				return "", fmt.Errorf("flag %s requires a value", name)
+			}
+			i++
+			return args[i], nil
+		}
+
+		var err error
+		switch name {
+		case "--pre":
+			opts.Pre = true
+		case "--no-hooks":
+			opts.NoHooks = true
+		case "--go-install":
+			opts.GoInstall = true
+		case "--global":
+			opts.Global = true
+		case "--dry-run":
+			opts.DryRun = true
+		case "--check":
+			opts.Check = true
+		case "--version":
+			opts.Version, err = needValue()
+		case "--format":
+			opts.Format, err = needValue()
+		case "--max-age":
+			opts.MaxAge, err = needValue()
+		case "--max-size":
+			opts.MaxSize, err = needValue()
+		case "--output":
+			opts.Output, err = needValue()
+			if err == nil && !validOutput(opts.Output) {
+				err = fmt.Errorf("unknown output format %q, must be one of %s", opts.Output, strings.Join(Outputs, ", "))
+			}
+		case "--platform":
+			var p string
+			p, err = needValue()
+			for _, p := range strings.Split(p, ",") {
+				if p != "" {
+					opts.Platforms = append(opts.Platforms, p)
+				}
+			}
+		case "--category":
+			var c string
+			c, err = needValue()
+			for _, c := range strings.Split(c, ",") {
+				if c != "" {
+					opts.Categories = append(opts.Categories, c)
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown flag %q", arg)
+		}
+		if err != nil {
+			return opts, nil, err
+		}
+	}
+	return opts, rest, nil
+}
+
+// apply sets the parts of tool changed by the options before it is
+// installed.
+func (opts options) apply(tool *Tool) {
+	if opts.Pre {
+		tool.Channel = ChannelPre
+	}
+	if opts.NoHooks {
+		tool.PostInstall = nil
+	}
+}
+
+func validOutput(format string) bool {
+	for _, o := range Outputs {
+		if o == format {
+			return true
+		}
+	}
+	return false
+}
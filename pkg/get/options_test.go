@@ -0,0 +1,47 @@
+package get
+
+import (
+	Z "github.com/rwxrob/bonzai/z"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	opts, rest, err := parseOptions(Cmd, []string{"k9s", "--pre", "--output=json", "--", "--no-hooks"}, "--pre", "--output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !opts.Pre || opts.Output != OutputJSON || !reflect.DeepEqual(rest, []string{"k9s", "--", "--no-hooks"}) {
+		t.Errorf("got %+v, %v", opts, rest)
+	}
+
+	tt := []struct {
+		cmd  *Z.Cmd
+		args []string
+		flag string
+	}{
+		{Cmd, []string{"k9s", "--dry-run"}, "--dry-run"},
+		{Cmd, []string{"--version", "v0.27.4", "k9s"}, "--version"},
+		{Cmd, []string{"k9s", "--bogus"}, "--bogus"},
+		{clean, []string{"--pre"}, "--pre"},
+		{SelfUpdate, []string{"--output=json"}, "--output"},
+		{syncLock, []string{"--platform", "linux/amd64"}, "--platform"},
+		{installed, []string{"--no-hooks"}, "--no-hooks"},
+	}
+	for _, tc := range tt {
+		err := tc.cmd.Call(tc.cmd, tc.args...)
+		if err == nil || !strings.Contains(err.Error(), "unknown flag "+tc.flag) || !strings.Contains(err.Error(), "usage: "+tc.cmd.Name) {
+			t.Errorf("%s %v: got %v, want a usage error for %s", tc.cmd.Name, tc.args, err, tc.flag)
+		}
+	}
+
+	err = Cmd.Call(Cmd, "--version", "v0.27.4", "k9s")
+	if err == nil || !strings.Contains(err.Error(), "TOOL@VERSION") {
+		t.Errorf("get --version: got %v, want a hint to use TOOL@VERSION", err)
+	}
+	opts, rest, err = parseOptions(SelfUpdate, []string{"--version", "v0.5.0", "--check"}, "--version", "--pre", "--check")
+	if err != nil || opts.Version != "v0.5.0" || !opts.Check || len(rest) != 0 {
+		t.Errorf("self-update --version: got %+v, %v, %v", opts, rest, err)
+	}
+}
@@ -0,0 +1,80 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/rwxrob/term"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+	OutputTSV   = "tsv"
+)
+
+// Outputs are the formats accepted by --output.
+var Outputs = []string{OutputTable, OutputJSON, OutputYAML, OutputTSV}
+
+// UseColor reports whether output should be coloured. Colour is disabled
+// when stdout is not a terminal or NO_COLOR is set.
+func UseColor() bool {
+	return term.IsInteractive() && os.Getenv("NO_COLOR") == ""
+}
+
+// view is a listing that can be written in any of the Outputs. Header and
+// Rows are used for tables and TSV, Value is encoded for JSON and YAML.
+type view struct {
+	Header  []string
+	Rows    [][]string
+	Value   any
+	Caption string
+}
+
+// write renders the view to w in the given format.
+func (v view) write(w io.Writer, format string) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v.Value)
+	case OutputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		defer enc.Close()
+		return enc.Encode(v.Value)
+	case OutputTSV:
+		fmt.Fprintln(w, strings.Join(v.Header, "\t"))
+		for _, row := range v.Rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		return nil
+	case OutputTable, "":
+		table := tablewriter.NewWriter(w)
+		table.SetColWidth(60)
+		table.SetHeader(v.Header)
+		table.AppendBulk(v.Rows)
+		if UseColor() {
+			header := []tablewriter.Colors{{tablewriter.Bold, tablewriter.FgGreenColor}}
+			column := []tablewriter.Colors{{tablewriter.Bold, tablewriter.FgHiGreenColor}}
+			for i := 1; i < len(v.Header); i++ {
+				header = append(header, tablewriter.Colors{tablewriter.Bold, tablewriter.Normal})
+				column = append(column, tablewriter.Colors{tablewriter.Normal, tablewriter.Normal})
+			}
+			table.SetHeaderColor(header...)
+			table.SetColumnColor(column...)
+		}
+		table.SetRowLine(true)
+		if v.Caption != "" {
+			table.SetCaption(true, v.Caption)
+		}
+		table.Render()
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q, must be one of %s", format, strings.Join(Outputs, ", "))
+}
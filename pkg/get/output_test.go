@@ -0,0 +1,68 @@
+package get
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestViewWrite(t *testing.T) {
+	type row struct {
+		Name    string `json:"name" yaml:"name"`
+		Version string `json:"version" yaml:"version"`
+	}
+	full := view{
+		Header:  []string{"Tool", "Version"},
+		Rows:    [][]string{{"k9s", "v0.27.4"}, {"jq", "jq-1.6"}},
+		Value:   []row{{"k9s", "v0.27.4"}, {"jq", "jq-1.6"}},
+		Caption: "2 tools.",
+	}
+	empty := view{Header: []string{"Tool", "Version"}, Value: []row{}}
+
+	tt := []struct {
+		name   string
+		view   view
+		format string
+		want   string
+	}{
+		{"json", full, OutputJSON, "[\n  {\n    \"name\": \"k9s\",\n    \"version\": \"v0.27.4\"\n  },\n  {\n    \"name\": \"jq\",\n    \"version\": \"jq-1.6\"\n  }\n]\n"},
+		{"yaml", full, OutputYAML, "- name: k9s\n  version: v0.27.4\n- name: jq\n  version: jq-1.6\n"},
+		{"tsv", full, OutputTSV, "Tool\tVersion\nk9s\tv0.27.4\njq\tjq-1.6\n"},
+		{"empty json", empty, OutputJSON, "[]\n"},
+		{"empty yaml", empty, OutputYAML, "[]\n"},
+		{"empty tsv", empty, OutputTSV, "Tool\tVersion\n"},
+	}
+
+	for _, tc := range tt {
+		var buf bytes.Buffer
+		err := tc.view.write(&buf, tc.format)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if buf.String() != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, buf.String(), tc.want)
+		}
+	}
+
+	for _, format := range []string{OutputTable, ""} {
+		var buf bytes.Buffer
+		err := full.write(&buf, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"TOOL", "VERSION", "k9s", "v0.27.4", "jq-1.6", "2 tools."} {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("table %q: %q missing from\n%s", format, s, buf.String())
+			}
+		}
+		if strings.Contains(buf.String(), "\x1b[") {
+			t.Errorf("table %q: coloured when stdout is not a terminal", format)
+		}
+	}
+
+	err := full.write(&bytes.Buffer{}, "xml")
+	if err == nil {
+		t.Error("xml: expected an error")
+	}
+}
@@ -0,0 +1,303 @@
+package get
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Package formats ds can pull a binary out of without a package manager.
+const (
+	PackageDeb = "deb"
+	PackageRPM = "rpm"
+	PackageAPK = "apk"
+)
+
+// packageFormat returns the package format of a download URL or an empty
+// string when it is not a distro package.
+func packageFormat(downloadURL string) string {
+	for _, f := range []string{PackageDeb, PackageRPM, PackageAPK} {
+		if strings.HasSuffix(downloadURL, "."+f) {
+			return f
+		}
+	}
+	return ""
+}
+
+// extractPackage writes the executable called name in the package read
+// from r to target/name. Only that file is extracted. When the package
+// holds several files of that name the one in a bin directory wins.
+func extractPackage(r io.Reader, format, name, target string) (string, error) {
+	var (
+		payload io.Reader
+		cpio    bool
+		err     error
+	)
+	switch format {
+	case PackageDeb:
+		payload, err = debData(r)
+	case PackageRPM:
+		payload, err = rpmPayload(r)
+		cpio = true
+	case PackageAPK:
+		// An apk is a series of gzip streams which together form one tar.
+		payload = r
+	default:
+		return "", fmt.Errorf("unsupported package format %q", format)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s package: %w", format, err)
+	}
+
+	data, wait, err := decompress(payload)
+	if err != nil {
+		return "", fmt.Errorf("%s package: %w", format, err)
+	}
+	x := &packageExtractor{name: name, dst: filepath.Join(target, name)}
+	if cpio {
+		err = x.cpio(data)
+	} else {
+		err = x.tar(data)
+	}
+	if werr := wait(); err == nil {
+		err = werr
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s package: %w", format, err)
+	}
+	if x.found == "" {
+		return "", fmt.Errorf("%s package does not contain %s", format, name)
+	}
+	return x.dst, nil
+}
+
+// packageExtractor writes the best match for name found so far to dst.
+type packageExtractor struct {
+	name  string
+	dst   string
+	found string
+	score int
+}
+
+// binaryScore rates the file at p as the executable called name: 0 when it
+// has another name, 2 in a bin directory and 1 anywhere else.
+func binaryScore(p, name string) int {
+	p = path.Clean("/" + p)
+	if path.Base(p) != name {
+		return 0
+	}
+	if strings.HasSuffix(path.Dir(p), "/bin") {
+		return 2
+	}
+	return 1
+}
+
+// consider writes the file at p from r when it is a better match for the
+// executable than the one already found.
+func (x *packageExtractor) consider(p string, r io.Reader) error {
+	score := binaryScore(p, x.name)
+	if score <= x.score {
+		return nil
+	}
+	p = path.Clean("/" + p)
+	f, err := os.OpenFile(x.dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0700)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	x.found, x.score = p, score
+	return nil
+}
+
+func (x *packageExtractor) tar(r io.Reader) error {
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag != tar.TypeReg && h.Typeflag != tar.TypeRegA {
+			continue
+		}
+		err = x.consider(h.Name, tr)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// cpio reads a "newc" cpio archive as used by rpm payloads.
+func (x *packageExtractor) cpio(r io.Reader) error {
+	br := bufio.NewReader(r)
+	var offset int64
+	skip := func(n int64) error {
+		_, err := io.CopyN(io.Discard, br, n)
+		offset += n
+		return err
+	}
+	pad := func() error {
+		if rem := offset % 4; rem != 0 {
+			return skip(4 - rem)
+		}
+		return nil
+	}
+	for {
+		hdr := make([]byte, 110)
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			return fmt.Errorf("cpio header: %w", err)
+		}
+		offset += 110
+		magic := string(hdr[:6])
+		if magic != "070701" && magic != "070702" {
+			return fmt.Errorf("unsupported cpio format %q", magic)
+		}
+		field := func(i int) (int64, error) {
+			return strconv.ParseInt(string(hdr[6+8*i:14+8*i]), 16, 64)
+		}
+		mode, err := field(1)
+		if err != nil {
+			return err
+		}
+		size, err := field(6)
+		if err != nil {
+			return err
+		}
+		nameSize, err := field(11)
+		if err != nil {
+			return err
+		}
+		nameBuf := make([]byte, nameSize)
+		if _, err := io.ReadFull(br, nameBuf); err != nil {
+			return err
+		}
+		offset += nameSize
+		name := strings.TrimRight(string(nameBuf), "\x00")
+		if name == "TRAILER!!!" {
+			return nil
+		}
+		if err := pad(); err != nil {
+			return err
+		}
+		if mode&0170000 == 0100000 {
+			lr := &io.LimitedReader{R: br, N: size}
+			if err := x.consider(name, lr); err != nil {
+				return err
+			}
+			offset += size - lr.N
+			if err := skip(lr.N); err != nil {
+				return err
+			}
+		} else if err := skip(size); err != nil {
+			return err
+		}
+		if err := pad(); err != nil {
+			return err
+		}
+	}
+}
+
+// debData returns the data.tar member of a deb, an ar archive.
+func debData(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, 8)
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != "!<arch>\n" {
+		return nil, errors.New("not an ar archive")
+	}
+	for {
+		hdr := make([]byte, 60)
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			return nil, errors.New("no data.tar member")
+		}
+		name := strings.TrimRight(strings.TrimSpace(string(hdr[:16])), "/")
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ar header for %q", name)
+		}
+		if strings.HasPrefix(name, "data.tar") {
+			return io.LimitReader(br, size), nil
+		}
+		// Members are padded to an even length.
+		if _, err := io.CopyN(io.Discard, br, size+size%2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// rpmPayload skips the lead and headers of an rpm and returns its
+// compressed cpio payload.
+func rpmPayload(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	lead := make([]byte, 96)
+	if _, err := io.ReadFull(br, lead); err != nil || !bytes.HasPrefix(lead, []byte{0xed, 0xab, 0xee, 0xdb}) {
+		return nil, errors.New("not an rpm")
+	}
+	// The signature header is padded to a multiple of 8 bytes, the main
+	// header is not.
+	for _, padded := range []bool{true, false} {
+		hdr := make([]byte, 16)
+		if _, err := io.ReadFull(br, hdr); err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(hdr, []byte{0x8e, 0xad, 0xe8}) {
+			return nil, errors.New("invalid rpm header")
+		}
+		entries := int64(binary.BigEndian.Uint32(hdr[8:12]))
+		size := int64(binary.BigEndian.Uint32(hdr[12:16]))
+		n := entries*16 + size
+		if padded && n%8 != 0 {
+			n += 8 - n%8
+		}
+		if _, err := io.CopyN(io.Discard, br, n); err != nil {
+			return nil, err
+		}
+	}
+	return br, nil
+}
+
+// decompress detects how r is compressed, by its magic bytes, and returns
+// its decompressed contents. gzip, bzip2, xz and zstd are supported. wait
+// must be called once the contents have been read.
+func decompress(r io.Reader) (io.Reader, func() error, error) {
+	none := func() error { return nil }
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(6)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		zr, err := gzip.NewReader(br)
+		return zr, none, err
+	case bytes.HasPrefix(magic, []byte("BZh")):
+		return bzip2.NewReader(br), none, nil
+	case bytes.HasPrefix(magic, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		xr, err := xz.NewReader(br)
+		return xr, none, err
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, none, err
+		}
+		return zr, func() error { zr.Close(); return nil }, nil
+	}
+	return br, none, nil
+}
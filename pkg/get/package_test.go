@@ -0,0 +1,195 @@
+package get
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testFiles are the files in every test package. The completion script
+// has the same name as the binary but is not in a bin directory.
+var testFiles = []struct{ Name, Body string }{
+	{"./usr/share/bash-completion/completions/hey", "completion"},
+	{"./usr/bin/hey", "binary"},
+	{"./usr/share/doc/hey/README", "readme"},
+}
+
+func testTar(t *testing.T, end bool) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range testFiles {
+		err := tw.WriteHeader(&tar.Header{Name: f.Name, Mode: 0755, Size: int64(len(f.Body)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.Body))
+	}
+	if end {
+		tw.Close()
+	} else {
+		tw.Flush()
+	}
+	return buf.Bytes()
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(data)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func testDeb(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	member := func(name string, data []byte) {
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", name, 0, 0, 0, "100644", len(data))
+		buf.Write(data)
+		if len(data)%2 == 1 {
+			buf.WriteByte('\n')
+		}
+	}
+	member("debian-binary", []byte("2.0\n"))
+	member("control.tar.gz", gzipped(t, []byte("x")))
+	member("data.tar.gz", gzipped(t, testTar(t, true)))
+	return buf.Bytes()
+}
+
+func testRPM(t *testing.T) []byte {
+	var cpio bytes.Buffer
+	entry := func(name string, mode int, body string) {
+		fmt.Fprintf(&cpio, "070701%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X%08X",
+			0, mode, 0, 0, 1, 0, len(body), 0, 0, 0, 0, len(name)+1, 0)
+		cpio.WriteString(name + "\x00")
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+		cpio.WriteString(body)
+		for cpio.Len()%4 != 0 {
+			cpio.WriteByte(0)
+		}
+	}
+	entry("./usr/bin", 040755, "")
+	for _, f := range testFiles {
+		entry(f.Name, 0100755, f.Body)
+	}
+	entry("TRAILER!!!", 0, "")
+
+	var buf bytes.Buffer
+	lead := make([]byte, 96)
+	copy(lead, []byte{0xed, 0xab, 0xee, 0xdb})
+	buf.Write(lead)
+	header := func(entries, size int, padded bool) {
+		buf.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+		binary.Write(&buf, binary.BigEndian, uint32(entries))
+		binary.Write(&buf, binary.BigEndian, uint32(size))
+		n := entries*16 + size
+		if padded && n%8 != 0 {
+			n += 8 - n%8
+		}
+		buf.Write(make([]byte, n))
+	}
+	header(1, 5, true)
+	header(2, 7, false)
+	buf.Write(gzipped(t, cpio.Bytes()))
+	return buf.Bytes()
+}
+
+func testAPK(t *testing.T) []byte {
+	var buf bytes.Buffer
+	buf.Write(gzipped(t, []byte{}))
+	buf.Write(gzipped(t, testTar(t, true)))
+	return buf.Bytes()
+}
+
+func TestExtractPackage(t *testing.T) {
+	tt := []struct {
+		format string
+		data   []byte
+	}{
+		{PackageDeb, testDeb(t)},
+		{PackageRPM, testRPM(t)},
+		{PackageAPK, testAPK(t)},
+	}
+	for _, tc := range tt {
+		dir := t.TempDir()
+		out, err := extractPackage(bytes.NewReader(tc.data), tc.format, "hey", dir)
+		if err != nil {
+			t.Errorf("%s: %s", tc.format, err)
+			continue
+		}
+		buf, _ := os.ReadFile(out)
+		if out != filepath.Join(dir, "hey") || string(buf) != "binary" {
+			t.Errorf("%s: extracted %q from %s", tc.format, buf, out)
+		}
+		entries, _ := os.ReadDir(dir)
+		if len(entries) != 1 {
+			t.Errorf("%s: extracted %d files, want 1", tc.format, len(entries))
+		}
+		_, err = extractPackage(bytes.NewReader(tc.data), tc.format, "missing", dir)
+		if err == nil {
+			t.Errorf("%s: found a file that is not in the package", tc.format)
+		}
+	}
+}
+
+func TestExtractPackageCompressed(t *testing.T) {
+	tt := []struct {
+		name     string
+		compress func(io.Writer) (io.WriteCloser, error)
+	}{
+		{"xz", func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }},
+		{"zst", func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }},
+	}
+	for _, tc := range tt {
+		var data bytes.Buffer
+		zw, err := tc.compress(&data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw.Write(testTar(t, true))
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		buf.WriteString("!<arch>\n")
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", "data.tar."+tc.name+"/", 0, 0, 0, "100644", data.Len())
+		buf.Write(data.Bytes())
+
+		out, err := extractPackage(&buf, PackageDeb, "hey", t.TempDir())
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if b, _ := os.ReadFile(out); string(b) != "binary" {
+			t.Errorf("%s: extracted %q", tc.name, b)
+		}
+	}
+}
+
+func TestPackageFormat(t *testing.T) {
+	tool := Tool{Name: "hey"}
+	for url, want := range map[string]string{
+		"https://example.com/hey_1.0_amd64.deb":     PackageDeb,
+		"https://example.com/hey-1.0.x86_64.rpm":    PackageRPM,
+		"https://example.com/hey-1.0-r0.apk":        PackageAPK,
+		"https://example.com/hey_1.0_linux.tar.gz":  "",
+		"https://example.com/hey_1.0_debian.tar.gz": "",
+	} {
+		if got := packageFormat(url); got != want {
+			t.Errorf("packageFormat(%s) = %q, want %q", url, got, want)
+		}
+		if ok, _ := tool.IsArchive(url); !ok {
+			t.Errorf("IsArchive(%s) = false", url)
+		}
+	}
+}
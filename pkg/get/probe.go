@@ -0,0 +1,243 @@
+package get
+
+import (
+	"context"
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// probeTimeout limits how long a binary may run when probing its version.
+var probeTimeout = 10 * time.Second
+
+// ProbeVersion runs the binary at path as described by the tool's
+// VersionProbe and returns the version it reports.
+func ProbeVersion(tool *Tool, path string) (string, error) {
+	p := Probe{Args: "--version"}
+	if tool.VersionProbe != nil {
+		p = *tool.VersionProbe
+	}
+	if p.Args == "" {
+		return "", fmt.Errorf("%s does not report its version", tool.Name)
+	}
+	if p.Regex == "" {
+		p.Regex = DefaultVersionRegex
+	}
+	re, err := regexp.Compile(p.Regex)
+	if err != nil {
+		return "", fmt.Errorf("%s: invalid version regex: %w", tool.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, strings.Fields(p.Args)...).CombinedOutput()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s %s: %w", path, p.Args, ctx.Err())
+	}
+	m := re.FindSubmatch(out)
+	if m == nil {
+		if err != nil {
+			return "", fmt.Errorf("%s %s: %w", path, p.Args, err)
+		}
+		return "", fmt.Errorf("no version found in the output of %s %s", path, p.Args)
+	}
+	if len(m) > 1 {
+		return string(m[1]), nil
+	}
+	return string(m[0]), nil
+}
+
+// ToolStatus is the version of a tool found on PATH compared with its
+// latest release.
+type ToolStatus struct {
+	Name    string `json:"name" yaml:"name"`
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version" yaml:"version"`
+	Latest  string `json:"latest" yaml:"latest"`
+	Managed bool   `json:"managed" yaml:"managed"`
+	Status  string `json:"status" yaml:"status"`
+}
+
+var status = &Z.Cmd{
+	Name:     `status`,
+	Summary:  `show the version of each tool on PATH [requires internet]`,
+	Usage:    `[--pre] [--output FORMAT] [TOOL...]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *status* command finds each registry tool on PATH, whether or not
+		ds installed it, runs it to find its version and compares that with
+		the latest release. Tools not found on PATH are left out unless they
+		are named.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--pre", "--output")
+		if err != nil {
+			return err
+		}
+		st, err := LoadState()
+		if err != nil {
+			return err
+		}
+		tools := Registry()
+		sort.Sort(tools)
+
+		var list []ToolStatus
+		for _, t := range tools {
+			if len(args) > 0 && !contains(args, t.Name) {
+				continue
+			}
+			ts := ToolStatus{Name: t.Name}
+			path, err := exec.LookPath(t.Name)
+			if err != nil {
+				if len(args) == 0 {
+					continue
+				}
+				ts.Status = "not found"
+				list = append(list, ts)
+				continue
+			}
+			ts.Path = path
+			if in, ok := st.Tools[t.Name]; ok && samePath(in.Path, path) {
+				ts.Managed = true
+			}
+			ts.Version, err = ProbeVersion(&t, path)
+			if err != nil {
+				ts.Status = err.Error()
+			}
+			ts.Latest, err = latestVersion(t.Name, tools, opts.Pre)
+			switch {
+			case err != nil:
+				ts.Latest = "unknown"
+			case ts.Status != "":
+			case newer(ts.Version, ts.Latest):
+				ts.Status = "outdated"
+			default:
+				ts.Status = "up to date"
+			}
+			list = append(list, ts)
+		}
+
+		v := view{Header: []string{"Tool", "Path", "Version", "Latest", "Managed", "Status"}, Value: list}
+		if list == nil {
+			v.Value = []ToolStatus{}
+		}
+		for _, ts := range list {
+			v.Rows = append(v.Rows, []string{ts.Name, ts.Path, ts.Version, ts.Latest, fmt.Sprint(ts.Managed), ts.Status})
+		}
+		return v.write(os.Stdout, opts.Output)
+	},
+}
+
+// samePath reports whether a and b are the same file once symlinks are
+// resolved.
+func samePath(a, b string) bool {
+	ra, err := filepath.EvalSymlinks(a)
+	if err != nil {
+		return false
+	}
+	rb, err := filepath.EvalSymlinks(b)
+	return err == nil && ra == rb
+}
+
+// Adopt copies an existing binary of the tool into the bin directory and
+// records it in the state file with the version it reports, so ds manages
+// it from then on. The original is left in place.
+func Adopt(tool *Tool, src string) (*Installed, error) {
+	version, err := ProbeVersion(tool, src)
+	if err != nil {
+		return nil, err
+	}
+	l, err := lockTool(tool.Name)
+	if err != nil {
+		return nil, err
+	}
+	defer l.Release()
+	dst, err := LocalBinary(tool.Name, "")
+	if err != nil {
+		return nil, err
+	}
+	if samePath(src, dst) {
+		return nil, fmt.Errorf("%s is already managed by ds", src)
+	}
+	_, err = InitUserDir()
+	if err != nil {
+		return nil, err
+	}
+	_, err = CopyFile(src, dst, 0700)
+	if err != nil {
+		return nil, err
+	}
+	sum, err := sha256File(dst)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return nil, err
+	}
+	in := &Installed{
+		Name:        tool.Name,
+		Version:     version,
+		Path:        dst,
+		URL:         "file://" + filepath.ToSlash(abs),
+		SHA256:      sum,
+		InstalledAt: time.Now().UTC(),
+	}
+	return in, recordInstall(*in)
+}
+
+var adopt = &Z.Cmd{
+	Name:     `adopt`,
+	Summary:  `bring an existing binary under ds management`,
+	Usage:    `[--output FORMAT] TOOL [PATH]`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *adopt* command copies an existing binary of a registry tool,
+		the one found on PATH or at PATH, into *~/.ds/bin* and records the
+		version it reports. From then on the tool is listed by *installed*
+		and *outdated* and can be upgraded with *ds get*. The original is
+		left in place and can be removed once ~/.ds/bin is on PATH.
+
+		    ds get adopt k9s
+		    ds get adopt gh /usr/local/bin/gh`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		opts, args, err := parseOptions(caller, args, "--output")
+		if err != nil {
+			return err
+		}
+		if len(args) < 1 || len(args) > 2 {
+			return caller.UsageError()
+		}
+		t, err := getTool(args[0], Registry())
+		if err != nil {
+			return err
+		}
+		src := ""
+		if len(args) == 2 {
+			src = args[1]
+		} else {
+			src, err = exec.LookPath(t.Name)
+			if err != nil {
+				return fmt.Errorf("%s not found on PATH, give the path of the binary to adopt", t.Name)
+			}
+		}
+		in, err := Adopt(&t, src)
+		if err != nil {
+			return err
+		}
+		log.Printf("Adopted %s %s from %s\n", in.Name, in.Version, src)
+		return view{
+			Header: []string{"Tool", "Version", "Path", "SHA256"},
+			Rows:   [][]string{{in.Name, in.Version, in.Path, in.SHA256}},
+			Value:  in,
+		}.write(os.Stdout, opts.Output)
+	},
+}
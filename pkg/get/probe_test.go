@@ -0,0 +1,63 @@
+package get
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProbeVersion(t *testing.T) {
+	tt := []struct {
+		name   string
+		probe  *Probe
+		output string
+		want   string
+		err    bool
+	}{
+		{"default", nil, "gh version 2.20.2 (2022-11-15)", "2.20.2", false},
+		{"prefixed", &Probe{Args: "version --short"}, "Version              v0.27.4\nCommit  abc", "v0.27.4", false},
+		{"jq", nil, "jq-1.6", "1.6", false},
+		{"submatch", &Probe{Args: "--version", Regex: `version=([^,\s]+)`}, "commit=abc, build date=2023, version=0.37.0, os=linux", "0.37.0", false},
+		{"hugo", &Probe{Args: "version", Regex: `v(\d+\.\d+\.\d+)`}, "hugo v0.111.3-5d4eb51+extended linux/amd64", "0.111.3", false},
+		{"no output", nil, "usage: tool", "", true},
+		{"disabled", &Probe{}, "1.0.0", "", true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			bin := filepath.Join(t.TempDir(), "tool")
+			script := "#!/bin/sh\ncat <<'EOF'\n" + tc.output + "\nEOF\n"
+			if err := os.WriteFile(bin, []byte(script), 0700); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ProbeVersion(&Tool{Name: "tool", VersionProbe: tc.probe}, bin)
+			if (err != nil) != tc.err || got != tc.want {
+				t.Errorf("ProbeVersion = %q, %v, want %q", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAdopt(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	src := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(src, []byte("#!/bin/sh\necho tool v1.2.3\n"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	in, err := Adopt(&Tool{Name: "tool"}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if in.Version != "v1.2.3" || in.Path != filepath.Join(BinDir(), "tool") {
+		t.Errorf("Adopt = %+v", in)
+	}
+	st, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Tools["tool"].Check(); err != nil {
+		t.Errorf("adopted tool not recorded: %v", err)
+	}
+	if _, err := Adopt(&Tool{Name: "tool"}, in.Path); err == nil {
+		t.Error("adopting a managed binary should fail")
+	}
+}
@@ -0,0 +1,208 @@
+package get
+
+import (
+	"errors"
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+)
+
+var shimsFilePath = ".ds/shims"
+
+// ProjectDir returns the nearest directory, starting with the current one,
+// that contains a LockFile.
+func ProjectDir() (string, bool) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", false
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, LockFile)); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// ProjectBinDir returns the directory tools are installed into for the
+// project in dir.
+func ProjectBinDir(dir string) string {
+	return filepath.Join(dir, toolFilePath)
+}
+
+// ShimsDir returns the directory shims are written to.
+func ShimsDir() string {
+	return filepath.Join(os.Getenv("HOME"), shimsFilePath)
+}
+
+// initProject switches installs to the project in dir and creates its .ds
+// directory, ignored by git, if needed.
+func initProject(dir string) error {
+	UseProject(dir)
+	ds := filepath.Dir(ProjectBinDir(dir))
+	err := mkdirp(ds)
+	if err != nil {
+		return err
+	}
+	ignore := filepath.Join(ds, ".gitignore")
+	if _, err := os.Stat(ignore); os.IsNotExist(err) {
+		return os.WriteFile(ignore, []byte("*\n"), 0644)
+	}
+	return nil
+}
+
+// projectPath returns $PATH with the bin directory of the nearest project,
+// if any, first and the shims directory removed so shims never find
+// themselves.
+func projectPath() string {
+	var dirs []string
+	if dir, ok := ProjectDir(); ok {
+		dirs = append(dirs, ProjectBinDir(dir))
+	}
+	shims := filepath.Clean(ShimsDir())
+	for _, p := range filepath.SplitList(os.Getenv("PATH")) {
+		if filepath.Clean(p) == shims || (len(dirs) > 0 && filepath.Clean(p) == filepath.Clean(dirs[0])) {
+			continue
+		}
+		dirs = append(dirs, p)
+	}
+	return strings.Join(dirs, string(os.PathListSeparator))
+}
+
+// runPassthrough runs the command attached to the terminal. If it fails ds
+// exits with the same code so callers see the command's status rather
+// than an error from ds. Interrupts are left for the command to handle.
+func runPassthrough(name string, args []string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	defer signal.Stop(sigs)
+
+	err := cmd.Run()
+	var ee *exec.ExitError
+	if errors.As(err, &ee) {
+		code := ee.ExitCode()
+		if status, ok := ee.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			code = 128 + int(status.Signal())
+		}
+		os.Exit(code)
+	}
+	return err
+}
+
+var execCmd = &Z.Cmd{
+	Name:     `exec`,
+	Summary:  `run a command with the project tools first on PATH`,
+	Usage:    `[--] COMMAND [ARG...]`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *exec* command runs COMMAND with the *.ds/bin* directory of the
+		nearest project, the directory holding *ds.lock*, first on PATH so
+		the versions pinned by the project are used. Outside a project the
+		command runs with PATH unchanged. The command's exit code is passed
+		through.
+
+		    ds exec -- hugo server
+		    ds exec -- goreleaser release --snapshot
+
+		Use *ds get shims* to run project tools without the *ds exec*
+		prefix.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		if len(args) > 0 && args[0] == "--" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return caller.UsageError()
+		}
+		err := os.Setenv("PATH", projectPath())
+		if err != nil {
+			return err
+		}
+		path, err := exec.LookPath(args[0])
+		if err != nil {
+			return err
+		}
+		return runPassthrough(path, args[1:])
+	},
+}
+
+// shim returns the file name and content of the shim for the tool. The
+// shim runs the tool through ds exec so the nearest project's version is
+// used.
+func shim(exe, name string) (string, string) {
+	if runtime.GOOS == "windows" {
+		return name + ".cmd", fmt.Sprintf("@\"%s\" exec -- %s %%*\r\n", exe, name)
+	}
+	return name, fmt.Sprintf("#!/bin/sh\n# Generated by ds get shims.\nexec \"%s\" exec -- %s \"$@\"\n", exe, name)
+}
+
+// WriteShims writes a shim for each of the named tools to ShimsDir.
+func WriteShims(names []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "ds"
+	}
+	err = mkdirp(ShimsDir())
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		file, content := shim(exe, name)
+		err = os.WriteFile(filepath.Join(ShimsDir(), file), []byte(content), 0755)
+		if err != nil {
+			return err
+		}
+		log.Printf("Wrote shim for %s\n", name)
+	}
+	return nil
+}
+
+var shims = &Z.Cmd{
+	Name:     `shims`,
+	Summary:  `write shims for the project tools`,
+	Usage:    `[TOOL...]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *shims* command writes a small script to *~/.ds/shims* for each
+		tool in the nearest *ds.lock*, or each tool named. A shim runs the
+		tool through *ds exec*, so inside a project the project's version is
+		used and elsewhere the one found on PATH. *ds shellenv* puts the
+		shims directory first on PATH.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		names := args
+		if len(names) == 0 {
+			dir, ok := ProjectDir()
+			if !ok {
+				return fmt.Errorf("no %s found, name the tools to write shims for", LockFile)
+			}
+			l, err := LoadLock(filepath.Join(dir, LockFile))
+			if err != nil {
+				return err
+			}
+			for _, t := range l.Tools {
+				names = append(names, t.Name)
+			}
+		}
+		if len(names) == 0 {
+			return caller.UsageError()
+		}
+		return WriteShims(names)
+	},
+}
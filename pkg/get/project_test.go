@@ -0,0 +1,44 @@
+package get
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	root := t.TempDir()
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0700); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	sep := string(os.PathListSeparator)
+	t.Setenv("PATH", strings.Join([]string{ShimsDir(), "/usr/bin"}, sep))
+	if _, ok := ProjectDir(); ok {
+		t.Fatal("found a project without a lock file")
+	}
+	if got := projectPath(); got != "/usr/bin" {
+		t.Errorf("outside a project PATH = %q, want the shims removed", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, LockFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir, ok := ProjectDir()
+	if real, _ := filepath.EvalSymlinks(root); !ok || (dir != root && dir != real) {
+		t.Fatalf("ProjectDir = %q, %v, want %q", dir, ok, root)
+	}
+	want := strings.Join([]string{ProjectBinDir(dir), "/usr/bin"}, sep)
+	if got := projectPath(); got != want {
+		t.Errorf("inside a project PATH = %q, want %q", got, want)
+	}
+}
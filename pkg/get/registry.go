@@ -0,0 +1,279 @@
+package get
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/web"
+	"github.com/olekukonko/tablewriter"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// releaseFixtures are the recorded release asset lists verify-registry
+// checks the tools against without internet access. Refresh them with
+// "ds get verify-registry record pkg/get/releases".
+//
+//go:embed releases
+var releaseFixtures embed.FS
+
+const releaseFixturesDir = "releases"
+
+// Platform is an operating system and architecture pair as reported by the
+// Go runtime.
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+func (p Platform) String() string { return p.OS + "/" + p.Arch }
+
+// Platforms is the matrix of platforms every registry entry is verified
+// against.
+var Platforms = []Platform{
+	{"linux", "amd64"},
+	{"linux", "arm64"},
+	{"linux", "arm"},
+	{"darwin", "amd64"},
+	{"darwin", "arm64"},
+	{"windows", "amd64"},
+}
+
+// releaseFixture is a recorded GitHub release used to check a tool's
+// BinaryTemplate without network access. Unsupported lists the platforms
+// the tool does not publish a binary for. Files lists the scripts of a
+// NonBinary tool found in the repository at the tag.
+type releaseFixture struct {
+	Tag         string   `json:"tag"`
+	Assets      []string `json:"assets"`
+	Files       []string `json:"files,omitempty"`
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+func (f releaseFixture) unsupported(p Platform) bool {
+	for _, u := range f.Unsupported {
+		if u == p.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// count returns the number of recorded assets with the given name.
+func (f releaseFixture) count(name string) int {
+	return countOf(f.Assets, name)
+}
+
+func countOf(list []string, name string) int {
+	n := 0
+	for _, asset := range list {
+		if asset == name {
+			n++
+		}
+	}
+	return n
+}
+
+// checkVerify ensures the checksum and signature assets configured for the
+// tool are part of the recorded release.
+func (f releaseFixture) checkVerify(tool *Tool, asset, opSystem, arch string) error {
+	checksums, signature, err := verifyNames(tool, asset, opSystem, arch, f.Tag)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{checksums, signature} {
+		if name != "" && f.count(name) != 1 {
+			return fmt.Errorf("no %q asset", name)
+		}
+	}
+	return nil
+}
+
+// RegistryResult is the outcome of rendering a tool's BinaryTemplate for
+// one platform and matching it against a recorded release.
+type RegistryResult struct {
+	Tool     string
+	Platform Platform
+	Asset    string
+	Matches  int
+	Skipped  bool
+	Err      error
+}
+
+// OK reports whether the rendered asset name matches exactly one asset.
+func (r RegistryResult) OK() bool {
+	return r.Skipped || (r.Err == nil && r.Matches == 1)
+}
+
+// Status is a short human readable description of the result.
+func (r RegistryResult) Status() string {
+	switch {
+	case r.Skipped:
+		return "unsupported"
+	case r.Err != nil:
+		return r.Err.Error()
+	case r.Matches == 0:
+		return "no matching asset"
+	case r.Matches > 1:
+		return fmt.Sprintf("%d matching assets", r.Matches)
+	}
+	return "ok"
+}
+
+// loadFixture reads the recorded release for the named tool from fsys.
+func loadFixture(fsys fs.FS, name string) (releaseFixture, error) {
+	var f releaseFixture
+	buf, err := fs.ReadFile(fsys, name+".json")
+	if err != nil {
+		return f, err
+	}
+	err = json.Unmarshal(buf, &f)
+	if err != nil {
+		return f, fmt.Errorf("failed to decode fixture %q: %w", name, err)
+	}
+	return f, nil
+}
+
+// VerifyRegistry renders every tool's BinaryTemplate for each of the
+// Platforms and checks the result against the recorded release for that
+// tool found in fsys.
+func VerifyRegistry(tools Tools, fsys fs.FS) []RegistryResult {
+	var results []RegistryResult
+	for _, tool := range tools {
+		tool := tool
+		fixture, err := loadFixture(fsys, tool.Name)
+		if err != nil {
+			results = append(results, RegistryResult{Tool: tool.Name, Err: err})
+			continue
+		}
+		for _, p := range Platforms {
+			res := RegistryResult{Tool: tool.Name, Platform: p}
+			if fixture.unsupported(p) {
+				res.Skipped = true
+				results = append(results, res)
+				continue
+			}
+			arch, opSystem := clientArch(p.OS, p.Arch)
+			res.Asset, res.Err = GetBinaryName(&tool, opSystem, arch, fixture.Tag)
+			res.Matches = fixture.count(res.Asset)
+			if tool.NonBinary {
+				res.Matches = countOf(fixture.Files, res.Asset)
+			}
+			if res.Err == nil && res.Matches == 1 {
+				res.Err = fixture.checkVerify(&tool, res.Asset, opSystem, arch)
+			}
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// RecordRegistry fetches the latest release for every tool and writes its
+// asset names to dir, keeping any platforms already marked as unsupported.
+func RecordRegistry(tools Tools, dir string) error {
+	err := mkdirp(dir)
+	if err != nil {
+		return err
+	}
+	for _, tool := range tools {
+		releases, err := FindGithubRelease(tool.Owner, tool.Repo)
+		if err != nil {
+			return fmt.Errorf("%s: %w", tool.Name, err)
+		}
+		if len(releases) == 0 {
+			return fmt.Errorf("%s: no releases found", tool.Name)
+		}
+		old, _ := loadFixture(os.DirFS(dir), tool.Name)
+		fixture := releaseFixture{
+			Tag:         releases[0].TagName,
+			Unsupported: old.Unsupported,
+		}
+		for _, asset := range releases[0].Assets {
+			fixture.Assets = append(fixture.Assets, asset.Name)
+		}
+		if tool.NonBinary {
+			file, err := GetBinaryName(&tool, "linux", "x86_64", fixture.Tag)
+			if err != nil {
+				return fmt.Errorf("%s: %w", tool.Name, err)
+			}
+			if scriptExists(&tool, fixture.Tag, file) {
+				fixture.Files = []string{file}
+			}
+		}
+		buf, err := json.MarshalIndent(fixture, "", "  ")
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(dir, tool.Name+".json"), append(buf, '\n'), 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("recorded %s %s\n", tool.Name, fixture.Tag)
+	}
+	return nil
+}
+
+var verifyRegistry = &Z.Cmd{
+	Name:     `verify-registry`,
+	Summary:  `check every tool's binary template against recorded releases`,
+	Usage:    `[record DIR]`,
+	Params:   []string{"record"},
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *verify-registry* command renders the binary template of every
+		tool for each supported operating system and architecture and checks
+		the name against a recorded list of that tool's release assets. It
+		does not require internet access. Any tool and platform pair that
+		does not match exactly one asset is reported as a failure.
+
+		Passing *record* and a directory will fetch the latest release of
+		every tool from GitHub and write the asset lists into that directory,
+		usually *pkg/get/releases* in a checkout of the ds source, so the
+		recordings built into the next release are refreshed.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		tools := MakeTools()
+		if len(args) > 0 {
+			if args[0] != "record" || len(args) != 2 {
+				return caller.UsageError()
+			}
+			return RecordRegistry(tools, args[1])
+		}
+
+		fsys, err := fs.Sub(releaseFixtures, releaseFixturesDir)
+		if err != nil {
+			return err
+		}
+		results := VerifyRegistry(tools, fsys)
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Tool", "Platform", "Asset", "Result"})
+		failed := 0
+		for _, r := range results {
+			if !r.OK() {
+				failed++
+			}
+			table.Append([]string{r.Tool, r.Platform.String(), r.Asset, r.Status()})
+		}
+		table.SetCaption(true, fmt.Sprintf("%d of %d checks failed.\n", failed, len(results)))
+		table.Render()
+		if failed > 0 {
+			return fmt.Errorf("registry verification failed")
+		}
+		return nil
+	},
+}
+
+// scriptExists reports whether the script at path is in the tool's
+// repository at tag.
+func scriptExists(tool *Tool, tag, path string) bool {
+	res, err := web.Client().Head(scriptURL(tool, tag, path))
+	if err != nil {
+		return false
+	}
+	res.Body.Close()
+	return res.StatusCode == http.StatusOK
+}
@@ -0,0 +1,39 @@
+package get
+
+import (
+	"io/fs"
+	"testing"
+)
+
+func TestVerifyRegistry(t *testing.T) {
+	fsys, err := fs.Sub(releaseFixtures, releaseFixturesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range VerifyRegistry(MakeTools(), fsys) {
+		if !r.OK() {
+			t.Errorf("%s %s: rendered %q: %s", r.Tool, r.Platform, r.Asset, r.Status())
+		}
+	}
+}
+
+func TestClientArch(t *testing.T) {
+	tt := []struct {
+		goos, goarch string
+		arch, os     string
+	}{
+		{"linux", "amd64", "x86_64", "linux"},
+		{"linux", "arm64", "aarch64", "linux"},
+		{"linux", "arm", "armv7l", "linux"},
+		{"darwin", "arm64", "arm64", "darwin"},
+		{"windows", "amd64", "x86_64", "mingw"},
+	}
+
+	for _, tc := range tt {
+		arch, os := clientArch(tc.goos, tc.goarch)
+		if arch != tc.arch || os != tc.os {
+			t.Errorf("%s/%s: got %s/%s, want %s/%s", tc.goos, tc.goarch, os, arch, tc.os, tc.arch)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package get
+
+import (
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	cacheFilePath = ".ds/cache"
+
+	// latestTTL is how long the latest version resolved by ds run is
+	// reused before GitHub is asked again.
+	latestTTL = 24 * time.Hour
+)
+
+// CacheDir returns the directory ds keeps cached downloads in.
+func CacheDir() string {
+	return filepath.Join(os.Getenv("HOME"), cacheFilePath)
+}
+
+// runCacheDir returns the directory the versions of the tool used by ds run
+// are cached in.
+func runCacheDir(name string) string {
+	return filepath.Join(CacheDir(), "run", name)
+}
+
+// cachedLatest returns the version last resolved as latest for the tool if
+// it was resolved within latestTTL and is still cached.
+func cachedLatest(name string) (string, bool) {
+	file := filepath.Join(runCacheDir(name), "latest")
+	fi, err := os.Stat(file)
+	if err != nil || time.Since(fi.ModTime()) > latestTTL {
+		return "", false
+	}
+	buf, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+	version := strings.TrimSpace(string(buf))
+	_, err = os.Stat(filepath.Join(runCacheDir(name), version, name))
+	return version, err == nil
+}
+
+// cachedVersions returns the versions of the tool in the run cache, newest
+// first.
+func cachedVersions(name string) []string {
+	entries, err := os.ReadDir(runCacheDir(name))
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() {
+			versions = append(versions, e.Name())
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return newer(versions[j], versions[i]) })
+	return versions
+}
+
+// RunBinary returns the path of the cached binary of the tool matching
+// version, downloading it into the cache first if it is missing. Nothing
+// is installed into the bin directory or recorded in the state file. The
+// version directory is touched so clean knows when it was last used.
+func RunBinary(tool *Tool, version string) (string, error) {
+	dir := runCacheDir(tool.Name)
+	bin := func(v string) string {
+		now := time.Now()
+		_ = os.Chtimes(filepath.Join(dir, v), now, now)
+		return filepath.Join(dir, v, tool.Name)
+	}
+
+	if version == "latest" {
+		if v, ok := cachedLatest(tool.Name); ok {
+			return bin(v), nil
+		}
+	} else if _, err := os.Stat(bin(version)); err == nil {
+		return bin(version), nil
+	}
+
+	arch, opSystem := GetClientArch()
+	asset, err := ResolveAsset(*tool, arch, opSystem, version)
+	if err != nil {
+		// Fall back to the newest cached version when offline.
+		if version == "latest" {
+			if cached := cachedVersions(tool.Name); len(cached) > 0 {
+				log.Printf("Using cached %s %s: %s\n", tool.Name, cached[0], err)
+				return bin(cached[0]), nil
+			}
+		}
+		return "", err
+	}
+	// Another run may be downloading the same tool, wait for it and check
+	// again once it is done.
+	l, err := filelock.Acquire(dir + ".lock")
+	if err != nil {
+		return "", err
+	}
+	defer l.Release()
+	path := bin(asset.Version)
+	if _, err := os.Stat(path); err != nil {
+		out, err := fetchBinary(tool, asset, "")
+		if err != nil {
+			return "", err
+		}
+		err = mkdirp(filepath.Dir(path))
+		if err != nil {
+			return "", err
+		}
+		// Copied under another name first as runs not waiting on the lock
+		// use the binary as soon as it exists.
+		_, err = CopyFile(out, path+".tmp", 0700)
+		if err != nil {
+			return "", err
+		}
+		err = os.Rename(path+".tmp", path)
+		if err != nil {
+			return "", err
+		}
+	}
+	if version == "latest" {
+		err = os.WriteFile(filepath.Join(dir, "latest"), []byte(asset.Version+"\n"), 0600)
+		if err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+var run = &Z.Cmd{
+	Name:     `run`,
+	Summary:  `download a tool on demand and run it [requires internet]`,
+	Usage:    `[--pre] TOOL[@VERSION] [--] [ARG...]`,
+	MinArgs:  1,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *run* command runs a tool without installing it. The tool is
+		downloaded into *~/.ds/cache/run* the first time it is used and
+		reused after that. The latest version is looked up again once a day.
+		Arguments after *--* are passed to the tool and its exit code is
+		passed through.
+
+		    ds run hey -- -n 100 http://localhost:8080
+		    ds run popeye@v0.11.1 -- --help`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		var toolArgs []string
+		for i, arg := range args {
+			if arg == "--" {
+				args, toolArgs = args[:i], args[i+1:]
+				break
+			}
+		}
+		opts, args, err := parseOptions(caller, args, "--pre")
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return caller.UsageError()
+		}
+		toolArgs = append(args[1:], toolArgs...)
+
+		name, version, _ := strings.Cut(args[0], "@")
+		t, err := getTool(name, Registry())
+		if err != nil {
+			return err
+		}
+		if version == "" {
+			version = t.Version
+		}
+		if version == "" {
+			version = "latest"
+		}
+		if opts.Pre {
+			t.Channel = ChannelPre
+		}
+		path, err := RunBinary(&t, version)
+		tempdir.Cleanup()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		return runPassthrough(path, toolArgs)
+	},
+}
@@ -0,0 +1,49 @@
+package get
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunBinaryCached(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	tool := &Tool{Name: "hey", Owner: "rakyll", Repo: "hey"}
+	for _, v := range []string{"v0.1.3", "v0.1.10", "v0.1.4"} {
+		path := filepath.Join(runCacheDir("hey"), v, "hey")
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, want := cachedVersions("hey"), []string{"v0.1.10", "v0.1.4", "v0.1.3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("cachedVersions = %v, want %v", got, want)
+	}
+
+	got, err := RunBinary(tool, "v0.1.4")
+	if err != nil || got != filepath.Join(runCacheDir("hey"), "v0.1.4", "hey") {
+		t.Errorf("RunBinary(v0.1.4) = %q, %v", got, err)
+	}
+
+	latest := filepath.Join(runCacheDir("hey"), "latest")
+	if err := os.WriteFile(latest, []byte("v0.1.3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	got, err = RunBinary(tool, "latest")
+	if err != nil || got != filepath.Join(runCacheDir("hey"), "v0.1.3", "hey") {
+		t.Errorf("RunBinary(latest) = %q, %v", got, err)
+	}
+
+	old := time.Now().Add(-2 * latestTTL)
+	if err := os.Chtimes(latest, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cachedLatest("hey"); ok {
+		t.Error("expired latest version was reused")
+	}
+}
@@ -0,0 +1,67 @@
+package get
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// rawContentURL serves the files of a GitHub repository at a given ref.
+var rawContentURL = "https://raw.githubusercontent.com"
+
+// scriptURL returns the URL of the script at path in the tool's repository
+// at tag.
+func scriptURL(tool *Tool, tag, path string) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", rawContentURL, tool.Owner, tool.Repo, tag, strings.TrimPrefix(path, "/"))
+}
+
+// interpreter returns the program named by the #! line of a script, or an
+// empty string when it has none. "#!/usr/bin/env bash" returns "bash".
+func interpreter(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && line == "" {
+		return "", nil
+	}
+	if !strings.HasPrefix(line, "#!") {
+		return "", nil
+	}
+	fields := strings.Fields(strings.TrimPrefix(line, "#!"))
+	if len(fields) == 0 {
+		return "", nil
+	}
+	if filepath.Base(fields[0]) != "env" {
+		return fields[0], nil
+	}
+	for _, f := range fields[1:] {
+		if !strings.HasPrefix(f, "-") {
+			return f, nil
+		}
+	}
+	return fields[0], nil
+}
+
+// checkInterpreter ensures the interpreter a script declares is installed.
+// Absolute paths must exist, other names are looked up on PATH.
+func checkInterpreter(tool *Tool, file string) error {
+	interp, err := interpreter(file)
+	if err != nil || interp == "" {
+		return err
+	}
+	if filepath.IsAbs(interp) {
+		_, err = os.Stat(interp)
+	} else {
+		_, err = exec.LookPath(interp)
+	}
+	if err != nil {
+		return fmt.Errorf("%s is a script run by %s, which is not installed", tool.Name, interp)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package get
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestInterpreter(t *testing.T) {
+	tt := []struct {
+		script, want string
+	}{
+		{"#!/usr/bin/env bash\necho hi\n", "bash"},
+		{"#!/usr/bin/env -S python3 -u\n", "python3"},
+		{"#! /bin/sh -e\n", "/bin/sh"},
+		{"echo no shebang\n", ""},
+		{"", ""},
+	}
+	for _, tc := range tt {
+		file := filepath.Join(t.TempDir(), "script")
+		if err := os.WriteFile(file, []byte(tc.script), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := interpreter(file)
+		if err != nil || got != tc.want {
+			t.Errorf("interpreter(%q) = %q, %v, want %q", tc.script, got, err, tc.want)
+		}
+	}
+
+	tool := &Tool{Name: "kubetail"}
+	file := filepath.Join(t.TempDir(), "script")
+	os.WriteFile(file, []byte("#!/usr/bin/env no-such-interpreter\n"), 0600)
+	if err := checkInterpreter(tool, file); err == nil {
+		t.Error("missing interpreter was not reported")
+	}
+}
+
+func TestInstallScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts are not run on windows")
+	}
+	t.Setenv("HOME", t.TempDir())
+	script := "#!/bin/sh\necho kubetail 1.6.20\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/johanhaleby/kubetail/1.6.20/kubetail" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, script)
+	}))
+	defer srv.Close()
+	defer func(u string) { rawContentURL = u }(rawContentURL)
+	rawContentURL = srv.URL
+
+	tool, err := getTool("kubetail", MakeTools())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool.PostInstall = nil
+	release := &GithubAPIReleasesResponse{TagName: "1.6.20"}
+	asset, err := releaseAsset(&tool, release, "x86_64", "linux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asset.URL != srv.URL+"/johanhaleby/kubetail/1.6.20/kubetail" {
+		t.Errorf("asset URL = %s", asset.URL)
+	}
+
+	in, err := installAsset(&tool, asset, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(in.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("%s is not executable: %s", in.Path, fi.Mode())
+	}
+	if v, err := ProbeVersion(&tool, in.Path); err != nil || v != "1.6.20" {
+		t.Errorf("ProbeVersion = %q, %v", v, err)
+	}
+}
@@ -0,0 +1,160 @@
+package get
+
+import (
+	"errors"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// selfPath returns the real path of the running executable with any
+// symlinks, such as one in /usr/local/bin, resolved.
+func selfPath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+// replaceExecutable replaces the executable at exe with the file at src
+// keeping its permissions. The new file is written next to exe and renamed
+// over it so exe is never left half written. Windows does not allow a
+// running executable to be replaced, only renamed, so there the old one is
+// moved aside to exe.old first and removed on the next update.
+func replaceExecutable(exe, src string) error {
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(exe)+".new-*")
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("%s is not writable, run self-update as the user that installed ds", dir)
+		}
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	_, err = CopyFile(src, tmpPath, int(fi.Mode().Perm()))
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmpPath, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		_ = os.Remove(old)
+		err = os.Rename(exe, old)
+		if err != nil {
+			return err
+		}
+		err = os.Rename(tmpPath, exe)
+		if err != nil {
+			_ = os.Rename(old, exe)
+		}
+		return err
+	}
+	return os.Rename(tmpPath, exe)
+}
+
+// updateSelf replaces exe, the running ds at version current, with the
+// release of tool selected by opts after verifying it against the
+// release's checksums, and reports the change to w.
+func updateSelf(w io.Writer, tool Tool, exe, current string, opts options) error {
+	if tool.Verify == nil || tool.Verify.Checksums == "" {
+		return fmt.Errorf("%s release has no checksums to verify against", tool.Name)
+	}
+	if opts.Pre {
+		tool.Channel = ChannelPre
+	}
+	version := opts.Version
+	if version == "" {
+		version = "latest"
+	}
+	arch, opSystem := GetClientArch()
+	asset, err := ResolveAsset(tool, arch, opSystem, version)
+	if err != nil {
+		return err
+	}
+
+	if asset.Version == current || (opts.Version == "" && !newer(current, asset.Version)) {
+		fmt.Fprintf(w, "%s %s is up to date\n", tool.Name, current)
+		return nil
+	}
+	if opts.Check {
+		fmt.Fprintf(w, "%s %s is available, %s is running\n", tool.Name, asset.Version, current)
+		return nil
+	}
+
+	out, err := fetchBinary(&tool, asset, "")
+	if err != nil {
+		return err
+	}
+	err = replaceExecutable(exe, out)
+	if err != nil {
+		return err
+	}
+	log.Printf("Replaced %s\n", exe)
+	fmt.Fprintf(w, "Updated %s from %s to %s\n", tool.Name, current, asset.Version)
+	return nil
+}
+
+var SelfUpdate = &Z.Cmd{
+	Name:     `self-update`,
+	Summary:  `replace ds with its latest release [requires internet]`,
+	Usage:    `[--version VERSION] [--pre] [--check]`,
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *self-update* command replaces the running ds binary, wherever it
+		is installed, with the latest release for this platform or the one
+		given with --version. Prereleases are considered with --pre. The
+		download is verified against the checksums.txt published with the
+		release before the binary is replaced, and the old binary is only
+		replaced once the new one is fully written.
+
+		With --check the command only reports whether a newer release is
+		available.
+
+		    ds self-update --check
+		    ds self-update --version v0.5.0`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		defer tempdir.Cleanup()
+		opts, args, err := parseOptions(caller, args, "--version", "--pre", "--check")
+		if err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			return caller.UsageError()
+		}
+		current := caller.Root().Version
+		if current == "" {
+			current = "dev"
+		}
+		// The built in entry is used so conf cannot point ds elsewhere.
+		tool, err := getTool("ds", MakeTools())
+		if err != nil {
+			return err
+		}
+		exe, err := selfPath()
+		if err != nil {
+			return err
+		}
+		return updateSelf(os.Stdout, tool, exe, current, opts)
+	},
+}
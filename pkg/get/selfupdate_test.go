@@ -0,0 +1,132 @@
+package get
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestReplaceExecutable(t *testing.T) {
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "ds")
+	err := os.WriteFile(exe, []byte("old"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(t.TempDir(), "ds")
+	err = os.WriteFile(src, []byte("new"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = replaceExecutable(exe, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "new" {
+		t.Errorf("got %q, want %q", b, "new")
+	}
+	fi, err := os.Stat(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && fi.Mode().Perm() != 0755 {
+		t.Errorf("got mode %s, want %s", fi.Mode().Perm(), os.FileMode(0755))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestUpdateSelf(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	binary := "ds v1.1.0"
+	sums := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			fmt.Fprintf(w, "%s  ds-bin\n", sums["ds-bin"])
+		case "/ds-bin":
+			io.WriteString(w, binary)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var releases []*GithubAPIReleasesResponse
+	err := json.Unmarshal([]byte(fmt.Sprintf(`[{"tag_name": "v1.1.0", "assets": [
+		{"name": "ds-bin", "browser_download_url": "%[1]s/ds-bin"},
+		{"name": "checksums.txt", "browser_download_url": "%[1]s/checksums.txt"}
+	]}]`, srv.URL)), &releases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	releaseCache.Lock()
+	releaseCache.repos["https://api.github.com/repos/example/ds/releases?per_page=100"] = releases
+	releaseCache.Unlock()
+
+	tool := Tool{Name: "ds", Owner: "example", Repo: "ds", BinaryTemplate: "ds-bin", Verify: &Verify{Checksums: "checksums.txt"}}
+	exe := filepath.Join(t.TempDir(), "ds")
+	err = os.WriteFile(exe, []byte("ds v1.0.0"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := func() string {
+		b, err := os.ReadFile(exe)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	sums["ds-bin"] = strings.Repeat("0", 64)
+	var out bytes.Buffer
+	err = updateSelf(&out, tool, exe, "v1.0.0", options{})
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") || read() != "ds v1.0.0" {
+		t.Fatalf("update with a bad checksum = %v, binary %q", err, read())
+	}
+
+	sum := sha256.Sum256([]byte(binary))
+	sums["ds-bin"] = hex.EncodeToString(sum[:])
+	tt := []struct {
+		current string
+		opts    options
+		want    string
+		binary  string
+	}{
+		{"v1.0.0", options{Check: true}, "ds v1.1.0 is available, v1.0.0 is running\n", "ds v1.0.0"},
+		{"v1.0.0", options{}, "Updated ds from v1.0.0 to v1.1.0\n", binary},
+		{"v1.1.0", options{}, "ds v1.1.0 is up to date\n", binary},
+		{"v1.2.0", options{}, "ds v1.2.0 is up to date\n", binary},
+	}
+	for _, tc := range tt {
+		out.Reset()
+		err := updateSelf(&out, tool, exe, tc.current, tc.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != tc.want || read() != tc.binary {
+			t.Errorf("%s %+v: got %q and binary %q, want %q and %q", tc.current, tc.opts, out.String(), read(), tc.want, tc.binary)
+		}
+	}
+}
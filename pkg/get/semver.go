@@ -0,0 +1,287 @@
+package get
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a release tag parsed as a semantic version. Tags are parsed
+// leniently since projects rarely agree on a format: the version starts at
+// the beginning of the tag or after a separator, as in "release-1.2" or
+// "jq-1.6", optionally prefixed with "v", and a missing minor or patch
+// number is treated as zero. Numbers after the patch, as in "1.2.3.4", are
+// build metadata and ignored like anything after a "+".
+type semver struct {
+	Major int
+	Minor int
+	Patch int
+	Pre   string
+
+	// parts is the number of version numbers present in the original
+	// string, used to expand partial versions such as "1.2" in constraints.
+	parts int
+}
+
+// versionStart returns the index in tag of the first digit of the version,
+// or -1 if there is none.
+func versionStart(tag string) int {
+	for i := 0; i < len(tag); i++ {
+		if i > 0 && !strings.ContainsRune("-_/ @", rune(tag[i-1])) {
+			continue
+		}
+		j := i
+		if tag[j] == 'v' || tag[j] == 'V' {
+			j++
+		}
+		if j < len(tag) && tag[j] >= '0' && tag[j] <= '9' {
+			return j
+		}
+	}
+	return -1
+}
+
+// parseSemver parses tag as a semantic version and reports whether it
+// contained a version at all.
+func parseSemver(tag string) (semver, bool) {
+	var v semver
+	i := versionStart(tag)
+	if i < 0 {
+		return v, false
+	}
+	s := tag[i:]
+	if j := strings.IndexByte(s, '+'); j >= 0 {
+		s = s[:j]
+	}
+
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		*nums[v.parts], _ = strconv.Atoi(s[:end])
+		v.parts++
+		s = s[end:]
+		if v.parts == len(nums) || len(s) < 2 || s[0] != '.' || s[1] < '0' || s[1] > '9' {
+			break
+		}
+		s = s[1:]
+	}
+	for len(s) > 1 && s[0] == '.' && s[1] >= '0' && s[1] <= '9' {
+		s = strings.TrimLeft(s[1:], "0123456789")
+	}
+	v.Pre = strings.TrimLeft(s, "-.")
+	return v, true
+}
+
+func (v semver) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// compare returns -1, 0 or 1 when v is lower, equal or higher than o. A
+// prerelease is lower than the release it precedes.
+func (v semver) compare(o semver) int {
+	for _, d := range []int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		if d < 0 {
+			return -1
+		}
+		if d > 0 {
+			return 1
+		}
+	}
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return comparePre(v.Pre, o.Pre)
+}
+
+// comparePre compares two prerelease strings by their dot separated
+// identifiers, numerically where both identifiers are numbers.
+func comparePre(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		switch {
+		case aerr == nil && berr == nil:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case as[i] != bs[i]:
+			if as[i] < bs[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// bump returns the lowest version above every version sharing the first n
+// version numbers of v. bump(1.2.3, 2) is 1.3.0.
+func (v semver) bump(n int) semver {
+	switch n {
+	case 0, 1:
+		return semver{Major: v.Major + 1, parts: 3}
+	case 2:
+		return semver{Major: v.Major, Minor: v.Minor + 1, parts: 3}
+	}
+	return semver{Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1, parts: 3}
+}
+
+// comparison is a single operator and version such as ">=1.2.0".
+type comparison struct {
+	op string
+	v  semver
+}
+
+func (c comparison) check(v semver) bool {
+	n := v.compare(c.v)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	}
+	return false
+}
+
+// constraint is a version range such as "~0.27", "^1.2" or
+// ">=0.110 <0.120". Comparisons separated by spaces or commas must all
+// hold and alternatives may be given with "||".
+type constraint [][]comparison
+
+// parseConstraint parses s into a constraint. Partial versions are
+// expanded so that "1.2", "1.2.x" and "1.2.*" all match any 1.2 patch.
+func parseConstraint(s string) (constraint, error) {
+	var c constraint
+	for _, alt := range strings.Split(s, "||") {
+		var all []comparison
+		fields := strings.FieldsFunc(alt, func(r rune) bool { return r == ' ' || r == ',' })
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			// allow a space between the operator and the version
+			if strings.Trim(f, "<>=!~^") == "" && i+1 < len(fields) {
+				i++
+				f += fields[i]
+			}
+			cmp, err := parseComparison(f)
+			if err != nil {
+				return nil, err
+			}
+			all = append(all, cmp...)
+		}
+		if len(all) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q", s)
+		}
+		c = append(c, all)
+	}
+	return c, nil
+}
+
+func parseComparison(s string) ([]comparison, error) {
+	op := ""
+	for _, o := range []string{"~>", ">=", "<=", "!=", "==", "=", ">", "<", "~", "^"} {
+		if strings.HasPrefix(s, o) {
+			op = o
+			break
+		}
+	}
+	ver := strings.TrimPrefix(strings.TrimPrefix(s, op), "v")
+	for strings.HasSuffix(ver, ".x") || strings.HasSuffix(ver, ".*") {
+		ver = ver[:len(ver)-2]
+	}
+	if ver == "x" || ver == "*" {
+		return []comparison{{">=", semver{}}}, nil
+	}
+	if ver == "" || ver[0] < '0' || ver[0] > '9' {
+		return nil, fmt.Errorf("invalid version %q", s)
+	}
+	v, _ := parseSemver(ver)
+
+	switch op {
+	case "~":
+		n := 2
+		if v.parts == 1 {
+			n = 1
+		}
+		return []comparison{{">=", v}, {"<", v.bump(n)}}, nil
+	case "~>":
+		return []comparison{{">=", v}, {"<", v.bump(v.parts - 1)}}, nil
+	case "^":
+		n := 1
+		switch {
+		case v.Major == 0 && v.Minor == 0 && v.parts == 3:
+			n = 3
+		case v.Major == 0 && v.parts >= 2:
+			n = 2
+		}
+		return []comparison{{">=", v}, {"<", v.bump(n)}}, nil
+	case "", "=", "==":
+		if v.parts < 3 && v.Pre == "" {
+			return []comparison{{">=", v}, {"<", v.bump(v.parts)}}, nil
+		}
+		return []comparison{{"=", v}}, nil
+	}
+	return []comparison{{op, v}}, nil
+}
+
+// check reports whether v satisfies the constraint.
+func (c constraint) check(v semver) bool {
+	for _, all := range c {
+		ok := true
+		for _, cmp := range all {
+			if !cmp.check(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsPre reports whether the constraint names a prerelease itself, in
+// which case prereleases are considered even on the stable channel.
+func (c constraint) allowsPre() bool {
+	for _, all := range c {
+		for _, cmp := range all {
+			if cmp.v.Pre != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
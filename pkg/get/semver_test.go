@@ -0,0 +1,88 @@
+package get
+
+import (
+	"testing"
+)
+
+func TestParseSemver(t *testing.T) {
+	tt := []struct {
+		tag  string
+		want string
+	}{
+		{"v0.27.4", "0.27.4"},
+		{"0.35.1", "0.35.1"},
+		{"jq-1.6", "1.6.0"},
+		{"release-1.2", "1.2.0"},
+		{"v2.0.0-rc.1", "2.0.0-rc.1"},
+		{"v1.2.3+build.5", "1.2.3"},
+		{"v1.0.0rc2", "1.0.0-rc2"},
+		{"k3s-v1.2.0", "1.2.0"},
+		{"v1.25.4+k3s1", "1.25.4"},
+		{"kustomize/v4.5.7", "4.5.7"},
+		{"1.2.3.4", "1.2.3"},
+		{"v1.2.3.4-rc.1", "1.2.3-rc.1"},
+	}
+
+	for _, tc := range tt {
+		v, ok := parseSemver(tc.tag)
+		if !ok {
+			t.Errorf("%q: failed to parse", tc.tag)
+			continue
+		}
+		if v.String() != tc.want {
+			t.Errorf("%q: got %s, want %s", tc.tag, v, tc.want)
+		}
+	}
+
+	for _, tag := range []string{"latest", "k3s", "mp3-player"} {
+		if _, ok := parseSemver(tag); ok {
+			t.Errorf("%q should not parse as a version", tag)
+		}
+	}
+}
+
+func TestConstraint(t *testing.T) {
+	tt := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"~0.27", "v0.27.4", true},
+		{"~0.27", "v0.28.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.2.2", false},
+		{"^1.2", "1.9.0", true},
+		{"^1.2", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{">=0.110 <0.120", "v0.115.4", true},
+		{">=0.110 <0.120", "v0.120.0", false},
+		{">= 0.110, < 0.120", "v0.109.0", false},
+		{"1.2.x", "1.2.7", true},
+		{"1.2", "1.3.0", false},
+		{"1.2.3", "v1.2.3", true},
+		{"~>1.2", "1.9.0", true},
+		{"~>1.2.3", "1.3.0", false},
+		{"<1.0.0 || >=2.0.0", "2.1.0", true},
+		{"<1.0.0 || >=2.0.0", "1.1.0", false},
+		{">=1.0.0", "1.0.0-rc.1", false},
+	}
+
+	for _, tc := range tt {
+		c, err := parseConstraint(tc.constraint)
+		if err != nil {
+			t.Errorf("%q: %v", tc.constraint, err)
+			continue
+		}
+		v, _ := parseSemver(tc.version)
+		if got := c.check(v); got != tc.want {
+			t.Errorf("%q with %q: got %v, want %v", tc.constraint, tc.version, got, tc.want)
+		}
+	}
+
+	for _, bad := range []string{"latest", ">=", "~foo"} {
+		if _, err := parseConstraint(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package get
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/danielmichaels/ds/pkg/web"
+	"golang.org/x/crypto/blake2b"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// maxVerifyAssetSize limits the size of checksum and signature files which
+// are read into memory.
+const maxVerifyAssetSize = 1 << 20
+
+// VerifyAsset checks the file downloaded for asset against the checksum and
+// signature assets configured in tool.Verify. It fails closed: a configured
+// asset missing from the release is an error.
+func VerifyAsset(tool *Tool, asset *Asset, file string) error {
+	v := tool.Verify
+	if v == nil {
+		return nil
+	}
+	checksums, signature, err := verifyNames(tool, asset.Name, asset.OS, asset.Arch, asset.Version)
+	if err != nil {
+		return err
+	}
+
+	var sums []byte
+	if checksums != "" {
+		sums, err = fetchReleaseAsset(asset, checksums)
+		if err != nil {
+			return err
+		}
+	}
+
+	if signature != "" {
+		sig, err := fetchReleaseAsset(asset, signature)
+		if err != nil {
+			return err
+		}
+		signed := sums
+		if signed == nil {
+			signed, err = os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+		}
+		err = verifySignature(v.Method, v.PublicKey, signed, sig)
+		if err != nil {
+			return fmt.Errorf("%s: %s signature %q: %w", tool.Name, v.Method, signature, err)
+		}
+		log.Printf("Verified %s signature %q\n", v.Method, signature)
+	}
+
+	if sums != nil {
+		want, err := findChecksum(sums, asset.Name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", tool.Name, err)
+		}
+		got, err := sha256File(file)
+		if err != nil {
+			return err
+		}
+		if !strings.EqualFold(got, want) {
+			return fmt.Errorf("%s: checksum mismatch for %q: got %s, want %s", tool.Name, asset.Name, got, want)
+		}
+		log.Printf("Verified checksum of %q\n", asset.Name)
+	}
+	return nil
+}
+
+// verifyNames renders the names of the checksum and signature assets of the
+// tool for the given binary asset.
+func verifyNames(tool *Tool, assetName, opSystem, arch, version string) (checksums, signature string, err error) {
+	v := tool.Verify
+	if v == nil {
+		return "", "", nil
+	}
+	if v.Signature != "" && v.PublicKey == "" {
+		return "", "", fmt.Errorf("%s: signature configured without a public key", tool.Name)
+	}
+	data := templateData(tool, opSystem, arch, version)
+	data["Asset"] = assetName
+	if v.Checksums != "" {
+		checksums, err = renderTemplate(tool.Name+"_checksums", v.Checksums, data)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	data["Checksums"] = checksums
+	if v.Signature != "" {
+		signature, err = renderTemplate(tool.Name+"_signature", v.Signature, data)
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return checksums, signature, nil
+}
+
+// fetchReleaseAsset downloads the named asset from the same release as
+// asset into memory.
+func fetchReleaseAsset(asset *Asset, name string) ([]byte, error) {
+	url, ok := asset.find(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no %q asset", asset.Version, name)
+	}
+	res, err := web.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code downloading %q: %d", name, res.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxVerifyAssetSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxVerifyAssetSize {
+		return nil, fmt.Errorf("%q is larger than %d bytes", name, maxVerifyAssetSize)
+	}
+	return data, nil
+}
+
+// sha256File returns the hex encoded SHA-256 sum of the file.
+func sha256File(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// findChecksum returns the sum for name from a checksum file in the format
+// written by sha256sum. A file holding a single bare sum is also accepted.
+func findChecksum(sums []byte, name string) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(sums)), "\n")
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 1 && len(lines) == 1:
+			return fields[0], nil
+		case len(fields) >= 2 && path.Base(strings.TrimPrefix(fields[len(fields)-1], "*")) == name:
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum found for %q", name)
+}
+
+// verifySignature checks the detached signature sig of signed using the
+// given method and public key.
+func verifySignature(method, key string, signed, sig []byte) error {
+	switch method {
+	case SignatureMinisign:
+		return verifyMinisign(key, signed, sig)
+	case SignatureCosign:
+		return verifyCosign(key, signed, sig)
+	case SignatureGPG:
+		return verifyGPG(key, signed, sig)
+	}
+	return fmt.Errorf("unknown signature method %q", method)
+}
+
+// lastLine returns the last non-empty line that is not a minisign comment,
+// allowing a key to be given as either a .pub file or its bare value.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		l := strings.TrimSpace(lines[i])
+		if l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			return l
+		}
+	}
+	return ""
+}
+
+// verifyMinisign verifies a minisign signature including its trusted
+// comment. Both legacy and prehashed (BLAKE2b) signatures are supported.
+func verifyMinisign(key string, signed, sig []byte) error {
+	pub, err := base64.StdEncoding.DecodeString(lastLine(key))
+	if err != nil || len(pub) != 42 || string(pub[:2]) != "Ed" {
+		return errors.New("invalid minisign public key")
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(sig)), "\n")
+	if len(lines) < 4 {
+		return errors.New("invalid minisign signature")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(raw) != 74 {
+		return errors.New("invalid minisign signature")
+	}
+	if !bytes.Equal(raw[2:10], pub[2:10]) {
+		return errors.New("signed with a different key")
+	}
+
+	msg := signed
+	switch string(raw[:2]) {
+	case "Ed":
+	case "ED":
+		h := blake2b.Sum512(signed)
+		msg = h[:]
+	default:
+		return fmt.Errorf("unsupported minisign algorithm %q", raw[:2])
+	}
+	pk := ed25519.PublicKey(pub[10:])
+	if !ed25519.Verify(pk, msg, raw[10:]) {
+		return errors.New("signature does not match")
+	}
+
+	trusted := strings.TrimSpace(lines[2])
+	if !strings.HasPrefix(trusted, "trusted comment: ") {
+		return errors.New("invalid minisign trusted comment")
+	}
+	trusted = strings.TrimPrefix(trusted, "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil {
+		return errors.New("invalid minisign signature")
+	}
+	msg = append(append([]byte{}, raw[10:]...), trusted...)
+	if !ed25519.Verify(pk, msg, global) {
+		return errors.New("trusted comment signature does not match")
+	}
+	return nil
+}
+
+// verifyCosign verifies a signature made with "cosign sign-blob --key", a
+// base64 encoded signature of the SHA-256 digest of the blob.
+func verifyCosign(key string, signed, sig []byte) error {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return errors.New("invalid cosign public key")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return errors.New("invalid cosign signature")
+	}
+
+	ok := false
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(signed)
+		ok = ecdsa.VerifyASN1(k, digest[:], raw)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, signed, raw)
+	default:
+		return fmt.Errorf("unsupported cosign key type %T", pub)
+	}
+	if !ok {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// verifyGPG verifies an armored (.asc) or binary (.sig) detached GPG
+// signature against an armored public key.
+func verifyGPG(key string, signed, sig []byte) error {
+	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(key))
+	if err != nil {
+		return err
+	}
+	check := openpgp.CheckDetachedSignature
+	if bytes.HasPrefix(bytes.TrimSpace(sig), []byte("-----BEGIN PGP")) {
+		check = openpgp.CheckArmoredDetachedSignature
+	}
+	_, err = check(keyring, bytes.NewReader(signed), bytes.NewReader(sig), nil)
+	return err
+}
@@ -0,0 +1,195 @@
+package get
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"golang.org/x/crypto/blake2b"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var signed = []byte("0b1f2c  ds_0.9.0_Linux_x86_64.tar.gz\n")
+
+// minisign builds a minisign public key and signature of data in the same
+// format written by the minisign tool.
+func minisign(t *testing.T, data []byte, prehash bool) (string, []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := []byte("ds-keyid")
+	key := base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pub...))
+
+	alg, msg := "Ed", data
+	if prehash {
+		h := blake2b.Sum512(data)
+		alg, msg = "ED", h[:]
+	}
+	sig := ed25519.Sign(priv, msg)
+	trusted := "timestamp:1670000000\tfile:checksums.txt"
+	global := ed25519.Sign(priv, append(append([]byte{}, sig...), trusted...))
+
+	var buf bytes.Buffer
+	buf.WriteString("untrusted comment: signature from minisign secret key\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(append(append([]byte(alg), id...), sig...)) + "\n")
+	buf.WriteString("trusted comment: " + trusted + "\n")
+	buf.WriteString(base64.StdEncoding.EncodeToString(global) + "\n")
+	return "untrusted comment: minisign public key\n" + key + "\n", buf.Bytes()
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	for _, prehash := range []bool{false, true} {
+		key, sig := minisign(t, signed, prehash)
+		if err := verifySignature(SignatureMinisign, key, signed, sig); err != nil {
+			t.Errorf("prehash %v: %v", prehash, err)
+		}
+		if err := verifySignature(SignatureMinisign, key, []byte("tampered"), sig); err == nil {
+			t.Errorf("prehash %v: tampered data verified", prehash)
+		}
+	}
+
+	other, _ := minisign(t, signed, false)
+	_, sig := minisign(t, signed, false)
+	if err := verifySignature(SignatureMinisign, other, signed, sig); err == nil {
+		t.Error("signature from another key verified")
+	}
+}
+
+func TestVerifyCosign(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	digest := sha256.Sum256(signed)
+	raw, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := []byte(base64.StdEncoding.EncodeToString(raw))
+
+	if err := verifySignature(SignatureCosign, key, signed, sig); err != nil {
+		t.Error(err)
+	}
+	if err := verifySignature(SignatureCosign, key, []byte("tampered"), sig); err == nil {
+		t.Error("tampered data verified")
+	}
+}
+
+func TestVerifyGPG(t *testing.T) {
+	entity, err := openpgp.NewEntity("ds", "", "ds@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key bytes.Buffer
+	w, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	var asc, bin bytes.Buffer
+	if err := openpgp.ArmoredDetachSign(&asc, entity, bytes.NewReader(signed), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := openpgp.DetachSign(&bin, entity, bytes.NewReader(signed), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, sig := range map[string][]byte{"asc": asc.Bytes(), "sig": bin.Bytes()} {
+		if err := verifySignature(SignatureGPG, key.String(), signed, sig); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if err := verifySignature(SignatureGPG, key.String(), []byte("tampered"), sig); err == nil {
+			t.Errorf("%s: tampered data verified", name)
+		}
+	}
+}
+
+func TestFindChecksum(t *testing.T) {
+	sums := []byte(`
+0b1f2c  ds_0.9.0_Linux_x86_64.tar.gz
+a9d8e7 *ds_0.9.0_Darwin_arm64.tar.gz
+`)
+	tt := []struct {
+		sums []byte
+		name string
+		want string
+	}{
+		{sums, "ds_0.9.0_Linux_x86_64.tar.gz", "0b1f2c"},
+		{sums, "ds_0.9.0_Darwin_arm64.tar.gz", "a9d8e7"},
+		{[]byte("ffee01\n"), "docker-compose-linux-x86_64", "ffee01"},
+	}
+	for _, tc := range tt {
+		got, err := findChecksum(tc.sums, tc.name)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := findChecksum(sums, "ds_0.9.0_Windows_x86_64.tar.gz"); err == nil {
+		t.Error("expected an error for a missing checksum")
+	}
+}
+
+func TestFetchReleaseAsset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			w.Write(signed)
+		case "/huge.txt":
+			w.Write(bytes.Repeat([]byte("a"), maxVerifyAssetSize+1))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var release GithubAPIReleasesResponse
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{"tag_name": "v0.9.0", "assets": [
+		{"name": "checksums.txt", "browser_download_url": "%[1]s/checksums.txt"},
+		{"name": "huge.txt", "browser_download_url": "%[1]s/huge.txt"}
+	]}`, srv.URL)), &release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	asset := &Asset{Version: "v0.9.0", Release: &release}
+
+	data, err := fetchReleaseAsset(asset, "checksums.txt")
+	if err != nil || !bytes.Equal(data, signed) {
+		t.Errorf("checksums.txt: got %q, %v", data, err)
+	}
+	_, err = fetchReleaseAsset(asset, "huge.txt")
+	if err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("huge.txt: got %v, want a size error", err)
+	}
+	_, err = fetchReleaseAsset(asset, "missing.txt")
+	if err == nil {
+		t.Error("missing.txt: expected an error")
+	}
+}
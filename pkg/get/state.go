@@ -0,0 +1,123 @@
+package get
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+var (
+	stateFilePath = ".ds/state.json"
+	locksFilePath = ".ds/locks"
+)
+
+// Installed records a tool installed by ds. It is also the result printed
+// when a tool is downloaded with a machine readable output format.
+type Installed struct {
+	Name        string    `json:"name" yaml:"name"`
+	Version     string    `json:"version" yaml:"version"`
+	Path        string    `json:"path" yaml:"path"`
+	URL         string    `json:"url" yaml:"url"`
+	SHA256      string    `json:"sha256" yaml:"sha256"`
+	InstalledAt time.Time `json:"installed_at" yaml:"installed_at"`
+}
+
+// State is the record of every tool installed by ds, kept in
+// ~/.ds/state.json or .ds/state.json in a project.
+type State struct {
+	Tools map[string]Installed `json:"tools"`
+}
+
+// StateFile returns the path of the state file.
+func StateFile() string {
+	return filepath.Join(InstallRoot(), stateFilePath)
+}
+
+// LoadState reads the state file. A missing file is an empty state.
+func LoadState() (*State, error) {
+	st := &State{Tools: map[string]Installed{}}
+	buf, err := os.ReadFile(StateFile())
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(buf, st)
+	if err != nil {
+		return nil, err
+	}
+	if st.Tools == nil {
+		st.Tools = map[string]Installed{}
+	}
+	return st, nil
+}
+
+// Save writes the state file. It is written to a temporary file first and
+// renamed so it is never seen half written.
+func (s *State) Save() error {
+	buf, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = mkdirp(filepath.Dir(StateFile()))
+	if err != nil {
+		return err
+	}
+	return filelock.WriteFile(StateFile(), append(buf, '\n'), 0600)
+}
+
+// List returns the installed tools sorted by name.
+func (s *State) List() []Installed {
+	var list []Installed
+	for _, in := range s.Tools {
+		list = append(list, in)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// recordInstall adds or replaces the record for a tool in the state file.
+// The state file is locked so concurrent installs do not lose each other's
+// records.
+func recordInstall(in Installed) error {
+	l, err := filelock.Acquire(StateFile() + ".lock")
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	st, err := LoadState()
+	if err != nil {
+		return err
+	}
+	st.Tools[in.Name] = in
+	return st.Save()
+}
+
+// Check returns an error if the installed binary is missing or has been
+// modified since it was installed.
+func (in Installed) Check() error {
+	sum, err := sha256File(in.Path)
+	if err != nil {
+		return err
+	}
+	if sum != in.SHA256 {
+		return fmt.Errorf("%s has changed since it was installed", in.Path)
+	}
+	return nil
+}
+
+// LocksDir returns the directory lock files are kept in.
+func LocksDir() string {
+	return filepath.Join(InstallRoot(), locksFilePath)
+}
+
+// lockTool locks the installation of the named tool so concurrent runs of
+// ds do not download and replace it at the same time.
+func lockTool(name string) (*filelock.Lock, error) {
+	return filelock.Acquire(filepath.Join(LocksDir(), name+".lock"))
+}
@@ -0,0 +1,31 @@
+package get
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRecordInstallConcurrent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := recordInstall(Installed{Name: fmt.Sprintf("tool%d", i), Version: "v1.0.0"})
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	st, err := LoadState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Tools) != 20 {
+		t.Errorf("state has %d tools, want 20", len(st.Tools))
+	}
+}
@@ -1,49 +1,207 @@
 package get
 
-import "strings"
-
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// templateFuncs are the functions available to a tool's templates. Those
+// changing a string take it last so they can end a pipeline, such as
+// {{.Version | TrimPrefix "release-"}}. Major, Minor and Patch return the
+// parts of a version or 0 when it cannot be parsed.
 var templateFuncs = map[string]interface{}{
-	"HasPrefix": func(s, prefix string) bool { return strings.HasPrefix(s, prefix) },
-	"ToLower":   strings.ToLower,
+	"HasPrefix":  func(s, prefix string) bool { return strings.HasPrefix(s, prefix) },
+	"HasSuffix":  func(s, suffix string) bool { return strings.HasSuffix(s, suffix) },
+	"TrimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"TrimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"Replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"ToLower":    strings.ToLower,
+	"ToUpper":    strings.ToUpper,
+	"Title":      title,
+	"Major":      func(v string) int { s, _ := parseSemver(v); return s.Major },
+	"Minor":      func(v string) int { s, _ := parseSemver(v); return s.Minor },
+	"Patch":      func(v string) int { s, _ := parseSemver(v); return s.Patch },
 }
 
+// title returns s with its first letter in upper case, such as "Linux" for
+// "linux".
+func title(s string) string {
+	r, n := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[n:]
+}
+
+// goOS and goArch convert the names from clientArch back to those of the
+// Go runtime, which OSNames, ArchNames and Ext are keyed by.
+func goOS(os string) string {
+	if strings.HasPrefix(os, "ming") {
+		return "windows"
+	}
+	return os
+}
+
+func goArch(arch string) string {
+	switch arch {
+	case "x86_64":
+		return "amd64"
+	case "aarch64":
+		return "arm64"
+	case "armv7l":
+		return "arm"
+	}
+	return arch
+}
+
+// platformNames returns the names the tool's releases use for the
+// platform and the extension of its asset, see OSNames, ArchNames and Ext.
+func (tool Tool) platformNames(os, arch string) (osName, archName, ext string) {
+	goos, goarch := goOS(os), goArch(arch)
+	osName, archName = goos, goarch
+	if n, ok := tool.OSNames[goos]; ok {
+		osName = n
+	}
+	if n, ok := tool.ArchNames[goarch]; ok {
+		archName = n
+	}
+	ext, ok := tool.Ext[goos]
+	if !ok {
+		ext = tool.Ext["default"]
+	}
+	return osName, archName, ext
+}
+
+const (
+	ChannelStable = "stable"
+	ChannelPre    = "pre"
+)
+
 type Tool struct {
 	// Name of the tool
-	Name string
+	Name string `yaml:"name,omitempty"`
 
 	// Repo is the GitHub repo
-	Repo string
+	Repo string `yaml:"repo,omitempty"`
 
 	// Owner is the tool Repo owner, such as
 	// derailed/k9s
-	Owner string
+	Owner string `yaml:"owner,omitempty"`
+
+	// Version to pull. An empty string means "latest". Either an exact tag
+	// or a constraint such as "~0.27" or ">=0.110 <0.120" may be used, in
+	// which case the highest matching release is pulled.
+	Version string `yaml:"version,omitempty"`
 
-	// Version to pull. An empty string means "latest"
-	Version string
+	// Channel selects which releases are considered "latest". The default,
+	// ChannelStable, skips prereleases whereas ChannelPre includes them.
+	// Drafts are always skipped.
+	Channel string `yaml:"channel,omitempty"`
 
 	// Description of what this tool does/is.
-	Description string
+	Description string `yaml:"description,omitempty"`
 
-	// NonBinary is used to determine if the tool is not a binary such as
-	// kubetail which is a bash script
-	NonBinary bool
+	// Tags are the categories the tool belongs to, such as "kubernetes" or
+	// "git", used to filter listings and as implicit groups.
+	Tags []string `yaml:"tags,omitempty"`
+
+	// NonBinary tools are scripts, such as kubetail, kept in the repository
+	// rather than published as release assets. Their BinaryTemplate is the
+	// path of the script in the repository, fetched at the release tag. The
+	// interpreter named by its #! line must be installed.
+	NonBinary bool `yaml:"non_binary,omitempty"`
 
 	// BinaryTemplate is the naming convention for a binary from GitHub.
 	// Using runtime.GOOS and runtime.GOARCH it is possible to determine the
 	// binary name, and BinaryTemplate must match it.
-	BinaryTemplate string
+	BinaryTemplate string `yaml:"binary_template,omitempty"`
+
+	// OSNames and ArchNames map the Go names of an operating system and
+	// architecture, such as "darwin" and "amd64", to those used by the
+	// tool's releases, given to templates as .OSName and .ArchName. Names
+	// without an entry are given as they are.
+	OSNames   map[string]string `yaml:"os_names,omitempty"`
+	ArchNames map[string]string `yaml:"arch_names,omitempty"`
+
+	// Ext is the extension of the asset for each operating system, such as
+	// ".zip" for "windows", given to templates as .Ext. The "default" entry
+	// is used for operating systems without one.
+	Ext map[string]string `yaml:"ext,omitempty"`
 
 	// URLTemplate specifies a Go template for the download URL
 	// override the OS, architecture and extension
 	// All whitespace will be trimmed
-	URLTemplate string
+	URLTemplate string `yaml:"url_template,omitempty"`
+
+	// Verify describes how a downloaded asset is checked before it is
+	// installed. Tools without it are installed unchecked.
+	Verify *Verify `yaml:"verify,omitempty"`
+
+	// PostInstall are run in order once the binary has been installed,
+	// such as generating shell completions. See Hook.
+	PostInstall []Hook `yaml:"post_install,omitempty"`
+
+	// VersionProbe finds the version of an existing binary of the tool. When
+	// nil the binary is run with --version and DefaultVersionRegex.
+	VersionProbe *Probe `yaml:"version_probe,omitempty"`
+
+	// GoModule is the package path of a Go tool's main package, such as
+	// "github.com/derailed/k9s". When set the tool can be built with go
+	// install at the release tag if no asset matches the platform.
+	GoModule string `yaml:"go_module,omitempty"`
+}
+
+// DefaultVersionRegex matches the first version number in a tool's output.
+const DefaultVersionRegex = `v?\d+\.\d+(?:\.\d+)?`
+
+// Probe runs a binary to find its version.
+type Probe struct {
+	// Args are passed to the binary, such as "version --short". A probe
+	// without Args means the tool cannot report its version.
+	Args string `yaml:"args,omitempty"`
+
+	// Regex extracts the version from the output. The first submatch is
+	// used if there is one, otherwise the whole match. It defaults to
+	// DefaultVersionRegex.
+	Regex string `yaml:"regex,omitempty"`
+}
+
+const (
+	SignatureMinisign = "minisign"
+	SignatureCosign   = "cosign"
+	SignatureGPG      = "gpg"
+)
+
+// Verify holds the checksum and signature settings of a tool. The names are
+// Go templates given the same values as BinaryTemplate plus .Asset, the
+// name of the binary asset, and .Checksums, the rendered checksum asset.
+type Verify struct {
+	// Checksums is the release asset listing the SHA-256 sum of each asset,
+	// such as "checksums.txt" or "{{.Asset}}.sha256".
+	Checksums string `yaml:"checksums,omitempty"`
+
+	// Signature is the release asset holding a detached signature such as
+	// "checksums.txt.sig" or "{{.Asset}}.minisig". It signs the Checksums
+	// asset when one is set and the binary asset otherwise. Installation
+	// fails when the release does not contain it.
+	Signature string `yaml:"signature,omitempty"`
+
+	// Method is the signature scheme, one of SignatureMinisign,
+	// SignatureCosign or SignatureGPG.
+	Method string `yaml:"method,omitempty"`
+
+	// PublicKey is the key the signature must be made with: a minisign
+	// public key, a PEM encoded cosign public key or an armored GPG key.
+	PublicKey string `yaml:"public_key,omitempty"`
 }
 
 // IsArchive determines if a binary is in archive format from the download URL.
 func (tool Tool) IsArchive(downloadURL string) (bool, error) {
 	return strings.HasSuffix(downloadURL, "tar.gz") ||
 		strings.HasSuffix(downloadURL, "zip") ||
-		strings.HasSuffix(downloadURL, "tgz"), nil
+		strings.HasSuffix(downloadURL, "tgz") ||
+		packageFormat(downloadURL) != "", nil
 }
 
 type Tools []Tool
@@ -68,294 +226,165 @@ func MakeTools() Tools {
 
 	tools = append(tools,
 		Tool{
-			Name:        "ds",
-			Repo:        "ds",
-			Owner:       "danielmichaels",
-			Description: "A command box for a danielmichaels things.",
-			NonBinary:   false,
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "Windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "Darwin"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{$osStr}}_{{$archStr}}.tar.gz`,
+			Name:           "ds",
+			Repo:           "ds",
+			Owner:          "danielmichaels",
+			Description:    "A command box for a danielmichaels things.",
+			Tags:           []string{"productivity"},
+			GoModule:       "github.com/danielmichaels/ds/cmd/ds",
+			NonBinary:      false,
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{Title .OSName}}_{{.ArchName}}.tar.gz`,
+			ArchNames:      map[string]string{"amd64": "x86_64"},
+			Verify: &Verify{
+				Checksums: "checksums.txt",
+			},
+			VersionProbe: &Probe{},
 		})
 	tools = append(tools,
 		Tool{
-			Name:        "zet-cmd",
-			Repo:        "zet-cmd",
-			Owner:       "danielmichaels",
-			Description: "A commander for your Zettelkasten notes.",
-			NonBinary:   false,
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "Windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "Darwin"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{$osStr}}_{{$archStr}}.tar.gz`,
+			Name:           "zet-cmd",
+			Repo:           "zet-cmd",
+			Owner:          "danielmichaels",
+			Description:    "A commander for your Zettelkasten notes.",
+			Tags:           []string{"docs", "productivity"},
+			NonBinary:      false,
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{Title .OSName}}_{{.ArchName}}.tar.gz`,
+			ArchNames:      map[string]string{"amd64": "x86_64"},
+			VersionProbe:   &Probe{},
 		})
 
 	tools = append(tools,
 		Tool{
-			Name:        "k9s",
-			Repo:        "k9s",
-			Owner:       "derailed",
-			Description: "A kubernetes TUI.",
-			NonBinary:   false,
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "Windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "Darwin"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- end -}}
-
-				{{.Name}}_{{$osStr}}_{{$archStr}}.tar.gz`,
+			Name:           "k9s",
+			Repo:           "k9s",
+			Owner:          "derailed",
+			Description:    "A kubernetes TUI.",
+			Tags:           []string{"kubernetes"},
+			GoModule:       "github.com/derailed/k9s",
+			NonBinary:      false,
+			BinaryTemplate: `{{.Name}}_{{Title .OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"arm": "armv7"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
+			PostInstall: []Hook{
+				{Action: HookCompletion, Args: "completion {{.Shell}}"},
+			},
+			VersionProbe: &Probe{Args: "version --short"},
 		})
 	tools = append(tools,
 		Tool{
-			Owner:       "derailed",
-			Repo:        "popeye",
-			Name:        "popeye",
-			Description: "Scans live Kubernetes cluster and reports potential issues with deployed resources and configurations.",
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "Windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "Darwin"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- end -}}
-
-				{{.Name}}_{{$osStr}}_{{$archStr}}.tar.gz`,
+			Owner:          "derailed",
+			Repo:           "popeye",
+			Name:           "popeye",
+			Description:    "Scans live Kubernetes cluster and reports potential issues with deployed resources and configurations.",
+			Tags:           []string{"kubernetes"},
+			GoModule:       "github.com/derailed/popeye",
+			BinaryTemplate: `{{.Name}}_{{Title .OSName}}_{{.ArchName}}.tar.gz`,
+			ArchNames:      map[string]string{"amd64": "x86_64", "arm": "armv7"},
+			VersionProbe:   &Probe{Args: "version"},
 		})
 	tools = append(tools,
 		Tool{
-			Owner:       "alexellis",
-			Repo:        "k3sup",
-			Name:        "k3sup",
-			Description: "Bootstrap Kubernetes with k3s over SSH < 1 min.",
-			BinaryTemplate: `{{ if HasPrefix .OS "ming" -}}
-				{{.Name}}.exe
-				{{- else if eq .OS "darwin" -}}
-				{{.Name}}-darwin
-				{{- else if eq .Arch "armv6l" -}}
-				{{.Name}}-armhf
-				{{- else if eq .Arch "armv7l" -}}
-				{{.Name}}-armhf
-				{{- else if eq .Arch "aarch64" -}}
-				{{.Name}}-arm64
-				{{- else -}}
-				{{.Name}}
-				{{- end -}}`,
+			Owner:          "alexellis",
+			Repo:           "k3sup",
+			Name:           "k3sup",
+			Description:    "Bootstrap Kubernetes with k3s over SSH < 1 min.",
+			Tags:           []string{"kubernetes"},
+			GoModule:       "github.com/alexellis/k3sup",
+			BinaryTemplate: `{{.Name}}{{with .OSName}}-{{.}}{{end}}{{with .ArchName}}-{{.}}{{end}}{{.Ext}}`,
+			OSNames:        map[string]string{"linux": "", "windows": ""},
+			ArchNames:      map[string]string{"amd64": "", "arm": "armhf"},
+			Ext:            map[string]string{"windows": ".exe"},
+			VersionProbe:   &Probe{Args: "version"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Name:        "arkade",
-			Repo:        "arkade",
-			Owner:       "alexellis",
-			Description: "Portable marketplace for downloading your favourite devops CLIs and installing helm charts, with a single command.",
-			BinaryTemplate: `
-			{{ if HasPrefix .OS "ming" -}}
-			{{.Name}}.exe
-			{{- else if eq .OS "darwin" -}}
-				{{ if eq .Arch "arm64" -}}
-				{{.Name}}-darwin-arm64
-				{{- else -}}
-				{{.Name}}-darwin
-				{{- end -}}
-			{{- else if eq .Arch "armv6l" -}}
-			{{.Name}}-armhf
-			{{- else if eq .Arch "armv7l" -}}
-			{{.Name}}-armhf
-			{{- else if eq .Arch "aarch64" -}}
-			{{.Name}}-arm64
-			{{- else -}}
-			{{.Name}}
-			{{- end -}}`,
+			Name:           "arkade",
+			Repo:           "arkade",
+			Owner:          "alexellis",
+			Description:    "Portable marketplace for downloading your favourite devops CLIs and installing helm charts, with a single command.",
+			Tags:           []string{"kubernetes"},
+			GoModule:       "github.com/alexellis/arkade",
+			BinaryTemplate: `{{.Name}}{{with .OSName}}-{{.}}{{end}}{{with .ArchName}}-{{.}}{{end}}{{.Ext}}`,
+			OSNames:        map[string]string{"linux": "", "windows": ""},
+			ArchNames:      map[string]string{"amd64": "", "arm": "armhf"},
+			Ext:            map[string]string{"windows": ".exe"},
+			VersionProbe:   &Probe{Args: "version"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "alexellis",
-			Repo:        "hey",
-			Name:        "hey",
-			Description: "Load testing tool",
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{- if eq .OS "linux" -}}
-					{{- if eq .Arch "x86_64" -}}
-						{{$osStr = ""}}
-					{{- else if eq .Arch "aarch64" -}}
-						{{$osStr = "-linux-arm64"}}
-					{{- else if eq .Arch "armv7l" -}}
-						{{$osStr = "-linux-armv7"}}
-					{{- end -}}
-				{{- else if eq .OS "darwin" -}}
-					{{- if eq .Arch "x86_64" -}}
-						{{$osStr = "-darwin-amd64"}}
-					{{- else if eq .Arch "arm64" -}}
-						{{$osStr = "-darwin-arm64"}}
-					{{- end -}}
-				{{ else if HasPrefix .OS "ming" -}}
-					{{$osStr =".exe"}}
-				{{- end -}}
-				
-				{{.Name}}{{$osStr}}`,
+			Owner:          "alexellis",
+			Repo:           "hey",
+			Name:           "hey",
+			Description:    "Load testing tool",
+			Tags:           []string{"network", "http"},
+			GoModule:       "github.com/rakyll/hey",
+			BinaryTemplate: `{{.Name}}{{if or (eq .OSName "darwin") (and (eq .OSName "linux") (ne .ArchName "amd64"))}}-{{.OSName}}-{{.ArchName}}{{end}}{{.Ext}}`,
+			ArchNames:      map[string]string{"arm": "armv7"},
+			Ext:            map[string]string{"windows": ".exe"},
+			VersionProbe:   &Probe{},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "openfaas",
-			Repo:        "faas-cli",
-			Name:        "faas-cli",
-			Description: "Official CLI for OpenFaaS.",
-			BinaryTemplate: `{{ if HasPrefix .OS "ming" -}}
-				{{.Name}}.exe
-				{{- else if eq .OS "darwin" -}}
-				{{.Name}}-darwin
-				{{- else if eq .Arch "armv6l" -}}
-				{{.Name}}-armhf
-				{{- else if eq .Arch "armv7l" -}}
-				{{.Name}}-armhf
-				{{- else if eq .Arch "aarch64" -}}
-				{{.Name}}-arm64
-				{{- else -}}
-				{{.Name}}
-				{{- end -}}`,
+			Owner:          "openfaas",
+			Repo:           "faas-cli",
+			Name:           "faas-cli",
+			Description:    "Official CLI for OpenFaaS.",
+			Tags:           []string{"kubernetes", "serverless"},
+			GoModule:       "github.com/openfaas/faas-cli",
+			BinaryTemplate: `{{.Name}}{{with .OSName}}-{{.}}{{end}}{{with .ArchName}}-{{.}}{{end}}{{.Ext}}`,
+			OSNames:        map[string]string{"linux": "", "windows": ""},
+			ArchNames:      map[string]string{"amd64": "", "arm": "armhf"},
+			Ext:            map[string]string{"windows": ".exe"},
+			VersionProbe:   &Probe{Args: "version --short-version"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "cli",
-			Repo:        "cli",
-			Name:        "gh",
-			Description: "GitHub’s official command line tool.",
-			BinaryTemplate: `
-				{{$extStr := "tar.gz"}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$extStr = "zip"}}
-				{{- end -}}
-
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "macOS"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- else if eq .Arch "x86_64" -}}
-				{{$archStr = "amd64"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{ ToLower $osStr}}_{{ ToLower $archStr}}.{{$extStr}}`,
+			Owner:          "cli",
+			Repo:           "cli",
+			Name:           "gh",
+			Description:    "GitHub’s official command line tool.",
+			Tags:           []string{"git"},
+			GoModule:       "github.com/cli/cli/v2/cmd/gh",
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{.OSName}}_{{.ArchName}}{{.Ext}}`,
+			OSNames:        map[string]string{"darwin": "macOS"},
+			ArchNames:      map[string]string{"arm": "armv6"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
+			PostInstall: []Hook{
+				{Action: HookCompletion, Args: "completion -s {{.Shell}}"},
+			},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "rs",
-			Repo:        "curlie",
-			Name:        "curlie",
-			Description: "The power of curl, the ease of use of httpie.",
-			BinaryTemplate: `
-				{{$extStr := "tar.gz"}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$extStr = "zip"}}
-				{{- end -}}
-
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "macOS"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- else if eq .Arch "x86_64" -}}
-				{{$archStr = "amd64"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{ ToLower $osStr}}_{{ ToLower $archStr}}.{{$extStr}}`,
+			Owner:          "rs",
+			Repo:           "curlie",
+			Name:           "curlie",
+			Description:    "The power of curl, the ease of use of httpie.",
+			Tags:           []string{"network", "http"},
+			GoModule:       "github.com/rs/curlie",
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{.OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"arm": "armv6"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
+			VersionProbe:   &Probe{Args: "version"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Name:        "rclone",
-			Repo:        "rclone",
-			Owner:       "rclone",
-			Description: "\"rsync for cloud storage\" - Google Drive, S3, Dropbox, Backblaze B2, One Drive, Swift, Hubic, Wasabi, Google Cloud Storage, Yandex Files",
-			NonBinary:   false,
-			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "Windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "Darwin"}}
-				{{- end -}}
-
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- else if eq .Arch "x86_64" -}}
-				{{$archStr = "amd64"}}
-				{{- end -}}
-
-				{{.Name}}-v{{.VersionNumber}}-{{$osStr}}-{{$archStr}}.zip`,
+			Name:           "rclone",
+			Repo:           "rclone",
+			Owner:          "rclone",
+			Description:    "\"rsync for cloud storage\" - Google Drive, S3, Dropbox, Backblaze B2, One Drive, Swift, Hubic, Wasabi, Google Cloud Storage, Yandex Files",
+			Tags:           []string{"network", "storage"},
+			GoModule:       "github.com/rclone/rclone",
+			NonBinary:      false,
+			BinaryTemplate: `{{.Name}}-v{{.VersionNumber}}-{{.OSName}}-{{.ArchName}}.zip`,
+			OSNames:        map[string]string{"darwin": "osx"},
+			ArchNames:      map[string]string{"arm": "arm-v7"},
+			VersionProbe:   &Probe{Args: "version"},
 		})
 
 	tools = append(tools,
@@ -364,121 +393,60 @@ func MakeTools() Tools {
 			Repo:        "hugo",
 			Owner:       "gohugoio",
 			Description: "The world’s fastest framework for building websites.",
+			Tags:        []string{"docs", "web"},
+			GoModule:    "github.com/gohugoio/hugo",
 			NonBinary:   false,
 			BinaryTemplate: `
-				{{$osStr := ""}}
-				{{- if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "darwin"}}
+				{{$archStr := .ArchName}}
+				{{- if eq .OSName "darwin" -}}
+				{{$archStr = "universal"}}
 				{{- end -}}
 
-				{{$archStr := .Arch}}
-				{{- if eq .Arch "armv7l" -}}
-				{{$archStr = "arm"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$archStr = "arm64"}}
-				{{- else if eq .Arch "x86_64" -}}
-				{{$archStr = "amd64"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{$osStr}}-{{$archStr}}.tar.gz`,
+				{{.Name}}_{{.VersionNumber}}_{{.OSName}}-{{$archStr}}{{.Ext}}`,
+			Ext: map[string]string{"default": ".tar.gz", "windows": ".zip"},
+			PostInstall: []Hook{
+				{Action: HookCompletion, Args: "completion {{.Shell}}"},
+			},
+			VersionProbe: &Probe{Args: "version", Regex: `v(\d+\.\d+\.\d+)`},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "goreleaser",
-			Repo:        "goreleaser",
-			Name:        "goreleaser",
-			Description: "Deliver Go binaries as fast and easily as possible",
-			BinaryTemplate: `
-		{{$osStr := ""}}
-		{{ if HasPrefix .OS "ming" -}}
-		{{$osStr = "Windows"}}
-		{{- else if eq .OS "linux" -}}
-		{{$osStr = "Linux"}}
-		{{- else if eq .OS "darwin" -}}
-		{{$osStr = "Darwin"}}
-		{{- end -}}
-
-		{{$archStr := ""}}
-		{{- if eq .Arch "x86_64" -}}
-		{{$archStr = "x86_64"}}
-		{{- else if eq .Arch "aarch64" -}}
-        {{$archStr = "arm64"}}
-		{{- end -}}
-
-		{{$archiveStr := ""}}
-		{{ if HasPrefix .OS "ming" -}}
-		{{$archiveStr = "zip"}}
-		{{- else -}}
-		{{$archiveStr = "tar.gz"}}
-		{{- end -}}
-
-		{{.Name}}_{{$osStr}}_{{$archStr}}.{{$archiveStr}}`,
+			Owner:          "goreleaser",
+			Repo:           "goreleaser",
+			Name:           "goreleaser",
+			Description:    "Deliver Go binaries as fast and easily as possible",
+			Tags:           []string{"release"},
+			BinaryTemplate: `{{.Name}}_{{Title .OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"amd64": "x86_64", "arm": "armv7"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
 		})
 	tools = append(tools,
 		Tool{
-			Owner:       "FiloSottile",
-			Repo:        "mkcert",
-			Name:        "mkcert",
-			Description: "A simple zero-config tool to make locally trusted development certificates with any names you'd like.",
-			BinaryTemplate: `
-				{{ $osStr := "" }}
-				{{ $archStr := "" }}
-				{{$archiveStr := ""}}
-				{{- if HasPrefix .OS "ming" -}}
-				{{$archiveStr = ".exe"}}
-				{{- else -}}
-				{{$archiveStr = ""}}
-				{{- end -}}
-	
-				{{ if HasPrefix .OS "ming" -}}
-				{{ $osStr = "windows" }}
-				{{- else if eq .OS "linux" -}}
-				{{ $osStr = "linux" }}
-				{{- else if eq .OS "darwin" -}}
-				{{ $osStr = "darwin" }}
-				{{- end -}}
-	
-				{{- if eq .Arch "x86_64" -}}
-				{{ $archStr = "amd64" }}
-				{{- else if eq .Arch "aarch64" -}}
-				{{ $archStr = "arm64" }}
-				{{- else if eq .Arch "armv7l" -}}
-				{{ $archStr = "arm" }}
-				{{- end -}}
-	
-				{{.Name}}-v{{.VersionNumber}}-{{$osStr}}-{{$archStr}}{{$archiveStr}}`,
+			Owner:          "FiloSottile",
+			Repo:           "mkcert",
+			Name:           "mkcert",
+			Description:    "A simple zero-config tool to make locally trusted development certificates with any names you'd like.",
+			Tags:           []string{"network", "security"},
+			GoModule:       "filippo.io/mkcert",
+			BinaryTemplate: `{{.Name}}-v{{.VersionNumber}}-{{.OSName}}-{{.ArchName}}{{.Ext}}`,
+			Ext:            map[string]string{"windows": ".exe"},
+			PostInstall: []Hook{
+				{Command: "{{.Path}} -install", OptIn: true},
+			},
+			VersionProbe: &Probe{Args: "-version"},
 		})
 	tools = append(tools,
 		Tool{
-			Owner:       "junegunn",
-			Repo:        "fzf",
-			Name:        "fzf",
-			Description: "General-purpose command-line fuzzy finder",
-			BinaryTemplate: `
-				{{ $osStr := "linux" }}
-				{{ $ext := ".tar.gz" }}
-				{{ if HasPrefix .OS "ming" -}}
-				{{ $osStr = "windows" }}
-				{{ $ext = ".zip" }}
-				{{- else if eq .OS "darwin" -}}
-				{{  $osStr = "darwin" }}
-				{{ $ext = ".zip" }}
-				{{- end -}}
-				{{ $archStr := "amd64" }}
-				{{- if eq .Arch "armv6l" -}}
-				{{ $archStr = "armv6" }}
-				{{- else if eq .Arch "armv7l" -}}
-				{{ $archStr = "armv7" }}
-				{{- else if eq .Arch "arm64" -}}
-				{{ $archStr = "arm64" }}
-				{{- else if eq .Arch "aarch64" -}}
-				{{ $archStr = "arm64" }}
-				{{- end -}}
-				{{.Name}}-{{.VersionNumber}}-{{$osStr}}_{{$archStr}}{{$ext}}
-				`,
+			Owner:          "junegunn",
+			Repo:           "fzf",
+			Name:           "fzf",
+			Description:    "General-purpose command-line fuzzy finder",
+			Tags:           []string{"shell"},
+			GoModule:       "github.com/junegunn/fzf",
+			BinaryTemplate: `{{.Name}}-{{.VersionNumber}}-{{.OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"arm": "armv7"},
+			Ext:            map[string]string{"default": ".tar.gz", "darwin": ".zip", "windows": ".zip"},
 		})
 	// k3sup
 
@@ -488,26 +456,15 @@ func MakeTools() Tools {
 			Repo:        "jq",
 			Name:        "jq",
 			Description: "jq is a lightweight and flexible command-line JSON processor",
-			BinaryTemplate: `{{$arch := "arm"}}
-				{{- if eq .Arch "x86_64" -}}
-				{{$arch = "64"}}
-				{{- else if eq .Arch "arm64" -}}
-				{{$arch = "64"}}
-				{{- else -}}
-				{{$arch = "32"}}
-				{{- end -}}
-
-				{{$ext := ""}}
-				{{$os := .OS}}
-
-				{{ if HasPrefix .OS "ming" -}}
-				{{$ext = ".exe"}}
-				{{$os = "win"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$os = "osx-amd"}}
-				{{- end -}}
-
-				jq-{{$os}}{{$arch}}{{$ext}}`,
+			Tags:        []string{"json", "shell"},
+			// jq 1.6 only has amd64 builds for macOS, which arm64 Macs run
+			// under Rosetta, and no arm builds for Linux.
+			BinaryTemplate: `{{if eq .OSName "osx-amd"}}jq-osx-amd64
+				{{- else if eq .ArchName "64" "32"}}jq-{{.OSName}}{{.ArchName}}{{.Ext}}
+				{{- end}}`,
+			OSNames:   map[string]string{"darwin": "osx-amd", "windows": "win"},
+			ArchNames: map[string]string{"amd64": "64", "386": "32"},
+			Ext:       map[string]string{"windows": ".exe"},
 		})
 
 	// kubectx
@@ -515,189 +472,131 @@ func MakeTools() Tools {
 	// kubetail
 	tools = append(tools,
 		Tool{
-			Owner:       "stern",
-			Repo:        "stern",
-			Name:        "stern",
-			Description: "Multi pod and container log tailing for Kubernetes.",
-			BinaryTemplate: `{{$arch := "arm"}}
-				{{- if eq .Arch "aarch64" -}}
-				{{$arch = "arm64"}}
-				{{- else if eq .Arch "x86_64" -}}
-				{{$arch = "amd64"}}
-				{{- end -}}
-
-				{{$os := .OS}}
-				{{$ext := "tar.gz"}}
-
-				{{ if HasPrefix .OS "ming" -}}
-				{{$os = "windows"}}
-				{{- end -}}
-
-				{{.Name}}_{{.VersionNumber}}_{{$os}}_{{$arch}}.tar.gz`,
+			Owner:          "stern",
+			Repo:           "stern",
+			Name:           "stern",
+			Description:    "Multi pod and container log tailing for Kubernetes.",
+			Tags:           []string{"kubernetes", "logs"},
+			GoModule:       "github.com/stern/stern",
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{.OSName}}_{{.ArchName}}.tar.gz`,
+			PostInstall: []Hook{
+				{Action: HookCompletion, Args: "--completion {{.Shell}}"},
+			},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "jesseduffield",
-			Repo:        "lazygit",
-			Name:        "lazygit",
-			Description: "A simple terminal UI for git commands.",
-			BinaryTemplate: `
-				{{$os := ""}}
-				{{$ext := "tar.gz" }}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$os = "Windows"}}
-				{{$ext = "zip" }}
-				{{- else if eq .OS "linux" -}}
-				{{$os = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$os = "Darwin"}}
-				{{- end -}}
-
-				{{$arch := .Arch}}
-				{{ if (or (eq .Arch "x86_64") (eq .Arch "amd64")) -}}
-				{{$arch = "x86_64"}}
-				{{- else if (or (eq .Arch "aarch64") (eq .Arch "arm64")) -}}
-				{{$arch = "arm64"}}
-				{{- end -}}
-				{{.Name}}_{{.VersionNumber}}_{{$os}}_{{$arch}}.{{$ext}}`,
+			Owner:          "jesseduffield",
+			Repo:           "lazygit",
+			Name:           "lazygit",
+			Description:    "A simple terminal UI for git commands.",
+			Tags:           []string{"git"},
+			GoModule:       "github.com/jesseduffield/lazygit",
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{Title .OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"amd64": "x86_64", "arm": "armv6"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
+			VersionProbe:   &Probe{Args: "--version", Regex: `version=([^,\s]+)`},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "jesseduffield",
-			Repo:        "lazydocker",
-			Name:        "lazydocker",
-			Description: "The lazier way to manage everything docker.",
-			BinaryTemplate: `
-				{{$os := ""}}
-				{{$ext := "tar.gz" }}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$os = "Windows"}}
-				{{$ext = "zip" }}
-				{{- else if eq .OS "linux" -}}
-				{{$os = "Linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$os = "Darwin"}}
-				{{- end -}}
-
-				{{$arch := .Arch}}
-				{{ if (or (eq .Arch "x86_64") (eq .Arch "amd64")) -}}
-				{{$arch = "x86_64"}}
-				{{- else if (or (eq .Arch "aarch64") (eq .Arch "arm64")) -}}
-				{{$arch = "arm64"}}
-				{{- end -}}
-				{{.Name}}_{{.VersionNumber}}_{{$os}}_{{$arch}}.{{$ext}}`,
+			Owner:          "jesseduffield",
+			Repo:           "lazydocker",
+			Name:           "lazydocker",
+			Description:    "The lazier way to manage everything docker.",
+			Tags:           []string{"docker"},
+			GoModule:       "github.com/jesseduffield/lazydocker",
+			BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{Title .OSName}}_{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"amd64": "x86_64", "arm": "armv7"},
+			Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "docker",
-			Repo:        "compose",
-			Name:        "docker-compose",
-			Description: "Define and run multi-container applications with Docker.",
-			BinaryTemplate: `
-				{{$arch := .Arch}}
-
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				  {{- if eq .Arch "armv7l" -}}
-				  {{ $arch = "armv7"}}
-				  {{- end }}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "darwin"}}
-				{{- end -}}
-				{{$ext := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$ext = ".exe"}}
-				{{- end -}}
-
-				{{.Name}}-{{$osStr}}-{{$arch}}{{$ext}}`,
+			Owner:          "docker",
+			Repo:           "compose",
+			Name:           "docker-compose",
+			Description:    "Define and run multi-container applications with Docker.",
+			Tags:           []string{"docker"},
+			BinaryTemplate: `{{.Name}}-{{.OSName}}-{{.ArchName}}{{.Ext}}`,
+			ArchNames:      map[string]string{"amd64": "x86_64", "arm64": "aarch64", "arm": "armv7"},
+			Ext:            map[string]string{"windows": ".exe"},
+			PostInstall: []Hook{
+				{Action: HookDockerPlugin},
+			},
+			VersionProbe: &Probe{Args: "version --short"},
 		})
 	tools = append(tools,
 		Tool{
-			Owner:       "nats-io",
-			Repo:        "natscli",
-			Name:        "nats",
-			Description: "Utility to interact with and manage NATS.",
-			BinaryTemplate: `{{$arch := .Arch}}
-				{{ if eq .Arch "x86_64" -}}
-				{{$arch = "amd64"}}
-				{{- else if eq .Arch "armv6l" -}}
-				{{$arch = "arm6"}}
-				{{- else if eq .Arch "armv7l" -}}
-				{{$arch = "arm7"}}
-				{{- else if eq .Arch "aarch64" -}}
-				{{$arch = "arm64"}}
-				{{- end -}}
-
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "darwin"}}
-				{{- end -}}
-
-				{{.Name}}-{{.VersionNumber}}-{{$osStr}}-{{$arch}}.zip`,
+			Owner:          "nats-io",
+			Repo:           "natscli",
+			Name:           "nats",
+			Description:    "Utility to interact with and manage NATS.",
+			Tags:           []string{"network", "messaging"},
+			GoModule:       "github.com/nats-io/natscli/nats",
+			BinaryTemplate: `{{.Name}}-{{.VersionNumber}}-{{.OSName}}-{{.ArchName}}.zip`,
+			ArchNames:      map[string]string{"arm": "arm7"},
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "argoproj",
-			Repo:        "argo-cd",
-			Name:        "argocd",
-			Description: "Declarative, GitOps continuous delivery tool for Kubernetes.",
-			BinaryTemplate: `
-				{{$arch := .Arch}}
-				{{- if eq .Arch "x86_64" -}}
-				{{$arch = "amd64"}}
-				{{- else if or (eq .Arch "aarch64") (eq .Arch "arm64") -}}
-				{{$arch = "arm64"}}
-				{{- end -}}
-
-				{{$osStr := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$osStr = "windows"}}
-				{{- else if eq .OS "linux" -}}
-				{{$osStr = "linux"}}
-				{{- else if eq .OS "darwin" -}}
-				{{$osStr = "darwin"}}
-				{{- end -}}
+			Owner:          "argoproj",
+			Repo:           "argo-cd",
+			Name:           "argocd",
+			Description:    "Declarative, GitOps continuous delivery tool for Kubernetes.",
+			Tags:           []string{"kubernetes", "gitops"},
+			BinaryTemplate: `{{.Name}}-{{.OSName}}-{{.ArchName}}{{.Ext}}`,
+			Ext:            map[string]string{"windows": ".exe"},
+			PostInstall: []Hook{
+				{Action: HookCompletion, Args: "completion {{.Shell}}"},
+			},
+			VersionProbe: &Probe{Args: "version --client --short"},
+		})
 
-				{{$ext := ""}}
-				{{ if HasPrefix .OS "ming" -}}
-				{{$ext = ".exe"}}
-				{{- end -}}
+	tools = append(tools,
+		Tool{
+			Owner:          "containerd",
+			Repo:           "nerdctl",
+			Name:           "nerdctl",
+			Description:    "Docker-compatible CLI for containerd, with support for Compose",
+			Tags:           []string{"docker", "containers"},
+			BinaryTemplate: `{{.Name}}-{{.VersionNumber}}-{{.OSName}}-{{.ArchName}}.tar.gz`,
+			ArchNames:      map[string]string{"arm": "arm-v7"},
+		})
 
-				argocd-{{$osStr}}-{{$arch}}{{$ext}}`,
+	tools = append(tools,
+		Tool{
+			Owner:          "johanhaleby",
+			Repo:           "kubetail",
+			Name:           "kubetail",
+			Description:    "Bash script to tail Kubernetes logs from multiple pods at the same time.",
+			Tags:           []string{"kubernetes", "logs"},
+			NonBinary:      true,
+			BinaryTemplate: `kubetail`,
 		})
 
 	tools = append(tools,
 		Tool{
-			Owner:       "containerd",
-			Repo:        "nerdctl",
-			Name:        "nerdctl",
-			Description: "Docker-compatible CLI for containerd, with support for Compose",
-			BinaryTemplate: `
-				{{ $file := "" }}
-				{{- if eq .OS "linux" -}}
-					{{- if eq .Arch "armv6l" -}}
-						{{ $file = "arm-v7.tar.gz" }}
-					{{- else if eq .Arch "armv7l" -}}
-						{{ $file = "arm-v7.tar.gz" }}
-					{{- else if eq .Arch "aarch64" -}}
-						{{ $file = "arm64.tar.gz" }}
-					{{- else -}}
-						{{ $file = "amd64.tar.gz" }}
-					{{- end -}}
-				{{- end -}}
+			Owner:          "ahmetb",
+			Repo:           "kubectx",
+			Name:           "kubectx",
+			Description:    "Switch between Kubernetes contexts.",
+			Tags:           []string{"kubernetes"},
+			NonBinary:      true,
+			BinaryTemplate: `kubectx`,
+			VersionProbe:   &Probe{Args: "-V"},
+		})
 
-				{{.Name}}-{{.VersionNumber}}-{{.OS}}-{{$file}}`,
+	tools = append(tools,
+		Tool{
+			Owner:          "ahmetb",
+			Repo:           "kubectx",
+			Name:           "kubens",
+			Description:    "Switch between Kubernetes namespaces.",
+			Tags:           []string{"kubernetes"},
+			NonBinary:      true,
+			BinaryTemplate: `kubens`,
+			VersionProbe:   &Probe{Args: "-V"},
 		})
 
 	// packer
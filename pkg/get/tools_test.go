@@ -0,0 +1,96 @@
+package get
+
+import "testing"
+
+func TestPlatformNames(t *testing.T) {
+	tool := Tool{
+		Name:           "tool",
+		BinaryTemplate: `{{.Name}}_{{.VersionNumber}}_{{.OSName}}_{{.ArchName}}{{.Ext}}`,
+		OSNames:        map[string]string{"darwin": "macOS"},
+		ArchNames:      map[string]string{"amd64": "x86_64", "arm": "armv6"},
+		Ext:            map[string]string{"default": ".tar.gz", "windows": ".zip"},
+	}
+
+	tt := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "tool_1.2.3_linux_x86_64.tar.gz"},
+		{"linux", "arm64", "tool_1.2.3_linux_arm64.tar.gz"},
+		{"linux", "arm", "tool_1.2.3_linux_armv6.tar.gz"},
+		{"darwin", "arm64", "tool_1.2.3_macOS_arm64.tar.gz"},
+		{"windows", "amd64", "tool_1.2.3_windows_x86_64.zip"},
+	}
+
+	for _, tc := range tt {
+		arch, os := clientArch(tc.goos, tc.goarch)
+		got, err := GetBinaryName(&tool, os, arch, "v1.2.3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s/%s: got %q, want %q", tc.goos, tc.goarch, got, tc.want)
+		}
+	}
+}
+
+func TestJQNames(t *testing.T) {
+	jq, err := getTool("jq", MakeTools())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		goos, goarch string
+		want         string
+	}{
+		{"linux", "amd64", "jq-linux64"},
+		{"linux", "arm64", ""},
+		{"linux", "arm", ""},
+		{"darwin", "amd64", "jq-osx-amd64"},
+		{"darwin", "arm64", "jq-osx-amd64"},
+		{"windows", "amd64", "jq-win64.exe"},
+	}
+
+	for _, tc := range tt {
+		arch, os := clientArch(tc.goos, tc.goarch)
+		got, err := GetBinaryName(&jq, os, arch, "jq-1.6")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("%s/%s: got %q, want %q", tc.goos, tc.goarch, got, tc.want)
+		}
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	tt := []struct {
+		tmpl string
+		want string
+	}{
+		{`{{Title .OS}}`, "Linux"},
+		{`{{.Version | TrimPrefix "release-"}}`, "1.22.3-rc.1"},
+		{`{{.Name | TrimSuffix "-cli"}}`, "tool"},
+		{`{{.Version | Replace "." "_"}}`, "release-1_22_3-rc_1"},
+		{`{{ToUpper .Arch}}`, "X86_64"},
+		{`{{Major .Version}}.{{Minor .Version}}.{{Patch .Version}}`, "1.22.3"},
+		{`{{Major "latest"}}`, "0"},
+	}
+
+	data := map[string]string{
+		"OS":      "linux",
+		"Arch":    "x86_64",
+		"Name":    "tool-cli",
+		"Version": "release-1.22.3-rc.1",
+	}
+	for _, tc := range tt {
+		got, err := renderTemplate("test", tc.tmpl, data)
+		if err != nil {
+			t.Fatalf("%s: %s", tc.tmpl, err)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.tmpl, got, tc.want)
+		}
+	}
+}
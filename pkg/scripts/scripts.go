@@ -8,11 +8,12 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"github.com/danielmichaels/ds/pkg/tempdir"
+	"github.com/danielmichaels/ds/pkg/web"
 	Z "github.com/rwxrob/bonzai/z"
 	"github.com/rwxrob/help"
 	json "github.com/rwxrob/json/pkg"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
@@ -22,13 +23,10 @@ import (
 var ScriptFiles embed.FS
 
 // tmpFileCreator creates a temp file containing the script data passed in. The
-// file is not removed in this function - it must be done in the caller.
+// file is created in the per-run temp directory which the caller must remove
+// with tempdir.Cleanup.
 func tmpFileCreator(script []byte) (string, error) {
-	tmp, err := ioutil.TempFile("/tmp", "ds-file")
-	if err != nil {
-		return "", err
-	}
-	f, err := os.OpenFile(tmp.Name(), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	f, err := tempdir.CreateTemp("ds-file")
 	if err != nil {
 		return "", err
 	}
@@ -40,7 +38,7 @@ func tmpFileCreator(script []byte) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return tmp.Name(), nil
+	return f.Name(), nil
 }
 
 // Retriever reads the file provided and returns its data. It expects a valid
@@ -59,6 +57,16 @@ func Retriever(filename string) (string, error) {
 	return script, nil
 }
 
+// Dependencies are the external programs used by the scripts commands,
+// mapped to the commands that need them.
+var Dependencies = map[string][]string{
+	"bash":       {"til", "pfsense-vm-manager"},
+	"curl":       {"weather"},
+	"date":       {"date"},
+	"docker":     {"hugo"},
+	"VBoxManage": {"pfsense-vm-manager"},
+}
+
 var Cmd = &Z.Cmd{
 	Name:    `scripts`,
 	Summary: `call custom scripts`,
@@ -96,7 +104,7 @@ func fetch(urls []string) *http.Response {
 	for _, url := range urls {
 		go func(ctx context.Context, url string) {
 			req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
-			resp, err := http.DefaultClient.Do(req)
+			resp, err := web.Client().Do(req)
 			if err == nil {
 				//log.Println(resp.Request.URL)
 				select {
@@ -204,13 +212,14 @@ func queryIpinfo(ip string) (string, error) {
 
 	var result map[string]interface{}
 
-	cl := json.Client
+	cl := *web.Client()
 	cl.CheckRedirect = func(r *http.Request, via []*http.Request) error {
 		for k, v := range via[0].Header {
 			r.Header[k] = v
 		}
 		return nil
 	}
+	json.Client = &cl
 	headers := map[string]string{}
 	headers["Authorization"] = bearer
 	req := json.Request{
@@ -248,7 +257,7 @@ var til = &Z.Cmd{
 		if err != nil {
 			return err
 		}
-		defer func() { _ = os.Remove(script) }()
+		defer tempdir.Cleanup()
 
 		return Z.Exec("bash", script, cmdlineArgs)
 	},
@@ -271,7 +280,7 @@ var pfsenseManager = &Z.Cmd{
 		if err != nil {
 			return err
 		}
-		defer func() { _ = os.Remove(script) }()
+		defer tempdir.Cleanup()
 
 		return Z.Exec("bash", script, cmdlineArgs)
 	},
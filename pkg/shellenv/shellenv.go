@@ -0,0 +1,273 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+package shellenv
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/danielmichaels/ds/pkg/filelock"
+	"github.com/danielmichaels/ds/pkg/get"
+	Z "github.com/rwxrob/bonzai/z"
+	"github.com/rwxrob/help"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	beginMarker = "# >>> ds shellenv >>>"
+	endMarker   = "# <<< ds shellenv <<<"
+)
+
+var Cmd = &Z.Cmd{
+	Name:     `shellenv`,
+	Summary:  `print the shell setup for ds managed tools`,
+	Usage:    `[--install|--uninstall] [bash|zsh|fish|nu]`,
+	Params:   []string{"bash", "zsh", "fish", "nu"},
+	Commands: []*Z.Cmd{help.Cmd},
+	Description: `
+		The *shellenv* command prints the PATH, MANPATH and completion setup
+		for the directories ds installs tools and shims into. The shell defaults to the
+		one named by $SHELL. The output is meant to be evaluated from a shell
+		rc file:
+
+		    eval "$(ds shellenv bash)"     # ~/.bashrc
+		    eval "$(ds shellenv zsh)"      # ~/.zshrc
+		    ds shellenv fish | source      # ~/.config/fish/config.fish
+
+		Nushell cannot evaluate generated code so its setup is written to
+		*env.nu* directly by *--install*.
+
+		Passing *--install* adds a block delimited by marker comments to the
+		shell's rc file, running ds by the absolute path it was installed
+		at so the block works before ds is on PATH. Running it again
+		replaces the block rather than adding another. *--uninstall*
+		removes the block.`,
+	Call: func(caller *Z.Cmd, args ...string) error {
+		install, uninstall := false, false
+		var rest []string
+		for _, arg := range args {
+			switch arg {
+			case "--install":
+				install = true
+			case "--uninstall":
+				uninstall = true
+			default:
+				rest = append(rest, arg)
+			}
+		}
+		if len(rest) > 1 || (install && uninstall) {
+			return caller.UsageError()
+		}
+
+		shell := filepath.Base(os.Getenv("SHELL"))
+		if len(rest) == 1 {
+			shell = rest[0]
+		}
+		if _, ok := scripts[shell]; !ok {
+			return fmt.Errorf("unsupported shell %q, must be one of bash, zsh, fish or nu", shell)
+		}
+
+		switch {
+		case install:
+			return Install(shell)
+		case uninstall:
+			return Uninstall(shell)
+		}
+		out, err := Script(shell)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+		return nil
+	},
+}
+
+var scripts = map[string]string{
+	"bash": `case ":${PATH}:" in
+  *":{{.BinDir}}:"*) ;;
+  *) export PATH="{{.BinDir}}:${PATH}" ;;
+esac
+case ":${PATH}:" in
+  *":{{.Shims}}:"*) ;;
+  *) export PATH="{{.Shims}}:${PATH}" ;;
+esac
+case ":${MANPATH:-}:" in
+  *":{{.ManDir}}:"*) ;;
+  *) export MANPATH="{{.ManDir}}:${MANPATH:-}" ;;
+esac
+for f in "{{.Completions}}"/*; do
+  [ -r "$f" ] && . "$f"
+done
+unset f
+complete -C "{{.Exe}}" ds
+`,
+	"zsh": `case ":${PATH}:" in
+  *":{{.BinDir}}:"*) ;;
+  *) export PATH="{{.BinDir}}:${PATH}" ;;
+esac
+case ":${PATH}:" in
+  *":{{.Shims}}:"*) ;;
+  *) export PATH="{{.Shims}}:${PATH}" ;;
+esac
+case ":${MANPATH:-}:" in
+  *":{{.ManDir}}:"*) ;;
+  *) export MANPATH="{{.ManDir}}:${MANPATH:-}" ;;
+esac
+fpath=("{{.Completions}}" ${fpath:#"{{.Completions}}"})
+autoload -Uz compinit && compinit -i
+autoload -U +X bashcompinit && bashcompinit
+complete -C "{{.Exe}}" ds
+`,
+	"fish": `contains "{{.BinDir}}" $PATH; or set -gx PATH "{{.BinDir}}" $PATH
+contains "{{.Shims}}" $PATH; or set -gx PATH "{{.Shims}}" $PATH
+set -q MANPATH; or set -gx MANPATH ""
+contains "{{.ManDir}}" $MANPATH; or set -gx MANPATH "{{.ManDir}}" $MANPATH
+contains "{{.Completions}}" $fish_complete_path; or set -gx fish_complete_path "{{.Completions}}" $fish_complete_path
+`,
+	"nu": `$env.PATH = ($env.PATH | split row (char esep) | prepend '{{.BinDir}}' | prepend '{{.Shims}}' | uniq)
+$env.MANPATH = ($env.MANPATH? | default '' | split row (char esep) | prepend '{{.ManDir}}' | uniq | str join (char esep))
+`,
+}
+
+// rcFiles are the files Install adds the setup to, relative to $HOME.
+var rcFiles = map[string]string{
+	"bash": ".bashrc",
+	"zsh":  ".zshrc",
+	"fish": ".config/fish/config.fish",
+	"nu":   ".config/nushell/env.nu",
+}
+
+// evalLines are written to the rc file by Install so the setup is
+// regenerated each time the shell starts. They run ds by its absolute path
+// since ds may not be on PATH until the setup has run.
+var evalLines = map[string]string{
+	"bash": `eval "$("{{.Exe}}" shellenv bash)"`,
+	"zsh":  `eval "$("{{.Exe}}" shellenv zsh)"`,
+	"fish": `"{{.Exe}}" shellenv fish | source`,
+}
+
+// Script returns the setup for shell.
+func Script(shell string) (string, error) {
+	return render(shell, scripts[shell])
+}
+
+// render executes the template text with the directories for shell and the
+// path of the running ds executable.
+func render(shell, text string) (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "ds"
+	}
+	t, err := template.New(shell).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, map[string]string{
+		"BinDir":      get.BinDir(),
+		"Shims":       get.ShimsDir(),
+		"ManDir":      get.ManDir(),
+		"Completions": get.CompletionsDir(shell),
+		"Exe":         exe,
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RCFile returns the rc file Install writes to for shell. Zsh honours
+// $ZDOTDIR.
+func RCFile(shell string) string {
+	home := os.Getenv("HOME")
+	if shell == "zsh" && os.Getenv("ZDOTDIR") != "" {
+		home = os.Getenv("ZDOTDIR")
+	}
+	return filepath.Join(home, rcFiles[shell])
+}
+
+// Install adds the setup for shell to its rc file, replacing any block
+// added previously.
+func Install(shell string) error {
+	text, ok := evalLines[shell]
+	if !ok {
+		text = scripts[shell]
+	}
+	body, err := render(shell, text)
+	if err != nil {
+		return err
+	}
+	return updateRC(RCFile(shell), strings.TrimSpace(body))
+}
+
+// Uninstall removes the block added by Install from the rc file for shell.
+func Uninstall(shell string) error {
+	return updateRC(RCFile(shell), "")
+}
+
+// updateRC replaces the marker delimited block in file with body, removing
+// it when body is empty. The file is locked while it is rewritten and
+// replaced in one rename so a shell starting meanwhile never reads half of
+// it.
+func updateRC(file, body string) error {
+	l, err := filelock.Acquire(filepath.Join(get.LocksDir(), "shellenv.lock"))
+	if err != nil {
+		return err
+	}
+	defer l.Release()
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	out := replaceBlock(string(data), body)
+	if out == string(data) {
+		fmt.Printf("%s is already up to date\n", file)
+		return nil
+	}
+	err = os.MkdirAll(filepath.Dir(file), 0700)
+	if err != nil {
+		return err
+	}
+	err = filelock.WriteFile(file, []byte(out), 0644)
+	if err != nil {
+		return err
+	}
+	if body == "" {
+		fmt.Printf("Removed ds shellenv from %s\n", file)
+	} else {
+		fmt.Printf("Added ds shellenv to %s, restart your shell to apply it\n", file)
+	}
+	return nil
+}
+
+// replaceBlock replaces the marker delimited block in rc with one containing
+// body, or removes it when body is empty. A new block is appended to the end
+// of rc.
+func replaceBlock(rc, body string) string {
+	block := ""
+	if body != "" {
+		block = beginMarker + "\n" + body + "\n" + endMarker + "\n"
+	}
+	start := strings.Index(rc, beginMarker)
+	end := strings.Index(rc, endMarker)
+	if start < 0 || end < start {
+		if block == "" {
+			return rc
+		}
+		if rc != "" && !strings.HasSuffix(rc, "\n") {
+			rc += "\n"
+		}
+		if rc != "" {
+			rc += "\n"
+		}
+		return rc + block
+	}
+	end += len(endMarker)
+	if end < len(rc) && rc[end] == '\n' {
+		end++
+	}
+	return rc[:start] + block + rc[end:]
+}
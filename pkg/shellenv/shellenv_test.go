@@ -0,0 +1,98 @@
+package shellenv
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReplaceBlock(t *testing.T) {
+	block := beginMarker + "\neval \"$(ds shellenv bash)\"\n" + endMarker + "\n"
+
+	tt := []struct {
+		name string
+		rc   string
+		body string
+		want string
+	}{
+		{
+			name: "added to empty file",
+			rc:   "",
+			body: `eval "$(ds shellenv bash)"`,
+			want: block,
+		},
+		{
+			name: "appended after existing content",
+			rc:   "alias ll='ls -l'",
+			body: `eval "$(ds shellenv bash)"`,
+			want: "alias ll='ls -l'\n\n" + block,
+		},
+		{
+			name: "replaced in place",
+			rc:   "a\n" + beginMarker + "\nold\n" + endMarker + "\nb\n",
+			body: `eval "$(ds shellenv bash)"`,
+			want: "a\n" + block + "b\n",
+		},
+		{
+			name: "removed",
+			rc:   "a\n" + block + "b\n",
+			body: "",
+			want: "a\nb\n",
+		},
+		{
+			name: "remove without a block is a no-op",
+			rc:   "a\n\n",
+			body: "",
+			want: "a\n\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := replaceBlock(tc.rc, tc.body)
+			if got != tc.want {
+				t.Fatalf("got:  %q\nwant: %q", got, tc.want)
+			}
+			if again := replaceBlock(got, tc.body); again != got {
+				t.Fatalf("not idempotent:\nfirst:  %q\nsecond: %q", got, again)
+			}
+		})
+	}
+}
+
+func TestInstall(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("ZDOTDIR", "")
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tt := []struct {
+		shell string
+		want  string
+	}{
+		{"bash", `eval "$("` + exe + `" shellenv bash)"`},
+		{"zsh", `eval "$("` + exe + `" shellenv zsh)"`},
+		{"fish", `"` + exe + `" shellenv fish | source`},
+	}
+	for _, tc := range tt {
+		if err := Install(tc.shell); err != nil {
+			t.Fatalf("%s: %v", tc.shell, err)
+		}
+		rc, err := os.ReadFile(RCFile(tc.shell))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(rc), "\n"+tc.want+"\n") {
+			t.Errorf("%s: rc file is %q, want it to contain %q", tc.shell, rc, tc.want)
+		}
+		if err := Uninstall(tc.shell); err != nil {
+			t.Fatalf("%s: %v", tc.shell, err)
+		}
+		rc, _ = os.ReadFile(RCFile(tc.shell))
+		if strings.Contains(string(rc), "shellenv") {
+			t.Errorf("%s: uninstall left %q", tc.shell, rc)
+		}
+	}
+}
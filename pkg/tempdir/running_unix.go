@@ -0,0 +1,19 @@
+//go:build !windows
+
+package tempdir
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// running reports whether a process with the pid exists.
+func running(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,14 @@
+package tempdir
+
+import "os"
+
+// running reports whether a process with the pid exists. FindProcess only
+// succeeds on Windows when it does.
+func running(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tempdir gives each run of ds a single temporary directory for
+// downloads, extracted archives and scripts. Commands remove it with
+// Cleanup when they finish and it is removed on SIGINT or SIGTERM.
+// Directories left behind by runs that were killed are removed the next
+// time one is created.
+package tempdir
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Prefix starts the name of every per-run directory, followed by the
+// process id of the run that created it.
+const Prefix = "ds-run-"
+
+var (
+	mu   sync.Mutex
+	dir  string
+	sigs chan os.Signal
+)
+
+// Dir returns the directory for this run, creating it on first use.
+func Dir() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if dir != "" {
+		return dir, nil
+	}
+	Sweep()
+	d, err := os.MkdirTemp("", fmt.Sprintf("%s%d-", Prefix, os.Getpid()))
+	if err != nil {
+		return "", err
+	}
+	dir = d
+
+	sigs = make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func(c chan os.Signal) {
+		sig, ok := <-c
+		if !ok {
+			return
+		}
+		Cleanup()
+		code := 130
+		if sig == syscall.SIGTERM {
+			code = 143
+		}
+		os.Exit(code)
+	}(sigs)
+	return dir, nil
+}
+
+// CreateTemp creates a new file in Dir, see os.CreateTemp for pattern.
+func CreateTemp(pattern string) (*os.File, error) {
+	d, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return os.CreateTemp(d, pattern)
+}
+
+// MkdirTemp creates a new directory in Dir, see os.MkdirTemp for pattern.
+func MkdirTemp(pattern string) (string, error) {
+	d, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(d, pattern)
+}
+
+// Cleanup removes the directory for this run, if it was created, and stops
+// handling signals for it. Dir creates a new one if called again.
+func Cleanup() {
+	mu.Lock()
+	defer mu.Unlock()
+	if sigs != nil {
+		signal.Stop(sigs)
+		close(sigs)
+		sigs = nil
+	}
+	if dir != "" {
+		os.RemoveAll(dir)
+		dir = ""
+	}
+}
+
+// Stale returns the per-run directories left by runs that are no longer
+// running.
+func Stale() []string {
+	entries, err := os.ReadDir(os.TempDir())
+	if err != nil {
+		return nil
+	}
+	var list []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), Prefix) {
+			continue
+		}
+		pid, _, _ := strings.Cut(strings.TrimPrefix(e.Name(), Prefix), "-")
+		n, err := strconv.Atoi(pid)
+		if err != nil || n == os.Getpid() || running(n) {
+			continue
+		}
+		list = append(list, filepath.Join(os.TempDir(), e.Name()))
+	}
+	return list
+}
+
+// Sweep removes the Stale directories.
+func Sweep() {
+	for _, d := range Stale() {
+		os.RemoveAll(d)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+package tempdir
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanup(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	f, err := CreateTemp("ds-file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	d, err := MkdirTemp("download-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, _ := Dir()
+	if filepath.Dir(f.Name()) != run || filepath.Dir(d) != run {
+		t.Errorf("%s and %s are not in %s", f.Name(), d, run)
+	}
+	if !strings.HasPrefix(filepath.Base(run), Prefix) {
+		t.Errorf("%s does not start with %s", run, Prefix)
+	}
+
+	Cleanup()
+	if _, err := os.Stat(run); !os.IsNotExist(err) {
+		t.Errorf("%s was not removed: %v", run, err)
+	}
+	Cleanup()
+}
+
+func TestStale(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	dead := filepath.Join(os.TempDir(), Prefix+"999999999-1")
+	self := filepath.Join(os.TempDir(), Prefix+"1-1")
+	for _, d := range []string{dead, self} {
+		if err := os.Mkdir(d, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := Stale(); len(got) != 1 || got[0] != dead {
+		t.Errorf("Stale = %v, want [%s]", got, dead)
+	}
+	Sweep()
+	if _, err := os.Stat(dead); !os.IsNotExist(err) {
+		t.Errorf("%s was not swept", dead)
+	}
+}
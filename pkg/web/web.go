@@ -0,0 +1,276 @@
+// Copyright 2022 ds Daniel Michaels
+// SPDX-License-Identifier: Apache-2.0
+
+// Package web is the HTTP layer shared by every ds command. Requests have
+// separate connect and idle timeouts rather than a single deadline so large
+// downloads are not cut short, failed requests are retried with backoff,
+// proxies are taken from the environment and an extra CA bundle can be
+// trusted from the ds configuration.
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	Z "github.com/rwxrob/bonzai/z"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// DialTimeout limits establishing a TCP connection.
+	DialTimeout = 10 * time.Second
+
+	// TLSHandshakeTimeout limits the TLS handshake.
+	TLSHandshakeTimeout = 10 * time.Second
+
+	// ResponseHeaderTimeout limits waiting for the response headers once the
+	// request has been sent.
+	ResponseHeaderTimeout = 30 * time.Second
+
+	// IdleTimeout limits how long reading the body may stall. It is reset
+	// by every read so slow but steady downloads never time out.
+	IdleTimeout = 60 * time.Second
+
+	// Retries is the number of times a failed request is retried.
+	Retries = 3
+
+	// Backoff is the delay before the first retry. It doubles on each
+	// following attempt.
+	Backoff = 500 * time.Millisecond
+
+	// UserAgent is sent with every request. The ds command sets it to
+	// include its version.
+	UserAgent = "ds/dev"
+
+	// CABundleQuery is the conf query for a PEM file of certificates
+	// trusted in addition to the system roots.
+	CABundleQuery = ".cabundle"
+)
+
+var (
+	client     *http.Client
+	clientOnce sync.Once
+)
+
+// Client returns the shared ds HTTP client. It has no overall timeout,
+// see the package variables for the timeouts that apply.
+func Client() *http.Client {
+	clientOnce.Do(func() {
+		client = &http.Client{Transport: &transport{}}
+	})
+	return client
+}
+
+// Get issues a GET request for url with the shared client.
+func Get(url string) (*http.Response, error) {
+	return Client().Get(url)
+}
+
+// transport adds the User-Agent, retries and body idle timeout to
+// requests. The underlying transport is built on first use so the CA
+// bundle is read after the configuration has been loaded.
+type transport struct {
+	once sync.Once
+	base http.RoundTripper
+	err  error
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(func() { t.base, t.err = newBaseTransport() })
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	req = req.Clone(req.Context())
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", UserAgent)
+	}
+
+	var res *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 {
+			if err := rewind(req); err != nil {
+				return nil, err
+			}
+		}
+		ctx, cancel := context.WithCancel(req.Context())
+		res, err = t.base.RoundTrip(req.WithContext(ctx))
+		if attempt >= Retries || !retryable(req, res, err) {
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+			res.Body = newIdleReader(res.Body, cancel)
+			return res, nil
+		}
+		wait := Backoff << attempt
+		if res != nil {
+			if after := retryAfter(res); after > 0 {
+				wait = after
+			}
+			io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+			res.Body.Close()
+		}
+		cancel()
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// newBaseTransport returns the transport requests are sent with.
+func newBaseTransport() (http.RoundTripper, error) {
+	pool, err := certPool()
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: DialTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       &tls.Config{RootCAs: pool},
+		TLSHandshakeTimeout:   TLSHandshakeTimeout,
+		ResponseHeaderTimeout: ResponseHeaderTimeout,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          10,
+		ForceAttemptHTTP2:     true,
+	}, nil
+}
+
+// certPool returns the system roots with the configured CA bundle added,
+// or nil to use the system roots unchanged.
+func certPool() (*x509.CertPool, error) {
+	file := CABundle()
+	if file == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("reading CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", file)
+	}
+	return pool, nil
+}
+
+// CABundle returns the path of the configured CA bundle, if any.
+func CABundle() string {
+	if Z.Conf == nil {
+		return ""
+	}
+	file, err := Z.Conf.Query(CABundleQuery)
+	if err != nil {
+		return ""
+	}
+	file = strings.TrimSpace(file)
+	if file == "null" {
+		return ""
+	}
+	if strings.HasPrefix(file, "~/") {
+		file = os.Getenv("HOME") + file[1:]
+	}
+	return file
+}
+
+// retryable reports whether a request that ended with res or err should be
+// tried again. Only requests whose body can be replayed are retried.
+func retryable(req *http.Request, res *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		return temporary(err)
+	}
+	return res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+}
+
+// temporary reports whether err is a network failure worth retrying.
+func temporary(err error) bool {
+	var nerr net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, io.EOF):
+		return true
+	case errors.As(err, &nerr) && nerr.Timeout():
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay requested by a Retry-After header given in
+// seconds.
+func retryAfter(res *http.Response) time.Duration {
+	secs, err := strconv.Atoi(res.Header.Get("Retry-After"))
+	if err != nil || secs <= 0 {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// rewind resets the request body before it is sent again.
+func rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+// idleReader cancels the request when no data has been read from the body
+// within IdleTimeout.
+type idleReader struct {
+	body   io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func newIdleReader(body io.ReadCloser, cancel context.CancelFunc) *idleReader {
+	return &idleReader{body: body, timer: time.AfterFunc(IdleTimeout, cancel), cancel: cancel}
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	if !r.timer.Stop() {
+		if err == nil || errors.Is(err, context.Canceled) {
+			return n, fmt.Errorf("no data received for %s: %w", IdleTimeout, context.DeadlineExceeded)
+		}
+		return n, err
+	}
+	r.timer.Reset(IdleTimeout)
+	return n, err
+}
+
+func (r *idleReader) Close() error {
+	r.timer.Stop()
+	err := r.body.Close()
+	r.cancel()
+	return err
+}
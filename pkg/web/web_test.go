@@ -0,0 +1,125 @@
+package web
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	Backoff = time.Millisecond
+	tt := []struct {
+		name     string
+		failures int32
+		status   int
+		want     int
+		calls    int32
+	}{
+		{"ok", 0, http.StatusOK, http.StatusOK, 1},
+		{"recovers", 2, http.StatusBadGateway, http.StatusOK, 3},
+		{"gives up", 10, http.StatusServiceUnavailable, http.StatusServiceUnavailable, int32(Retries) + 1},
+		{"no retry on client error", 10, http.StatusNotFound, http.StatusNotFound, 1},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("User-Agent") != UserAgent {
+					t.Errorf("User-Agent = %q, want %q", r.Header.Get("User-Agent"), UserAgent)
+				}
+				if atomic.AddInt32(&calls, 1) <= tc.failures {
+					w.WriteHeader(tc.status)
+					return
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer srv.Close()
+
+			res, err := Get(srv.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != tc.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, tc.want)
+			}
+			if calls != tc.calls {
+				t.Errorf("calls = %d, want %d", calls, tc.calls)
+			}
+		})
+	}
+}
+
+func TestTemporary(t *testing.T) {
+	dial := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: err}}
+	}
+	tt := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", dial(syscall.ECONNREFUSED), true},
+		{"connection reset", dial(syscall.ECONNRESET), true},
+		{"unexpected eof", io.ErrUnexpectedEOF, true},
+		{"timeout", dial(&net.DNSError{Err: "i/o timeout", IsTimeout: true}), true},
+		{"no such host", dial(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}), false},
+		{"network unreachable", dial(syscall.ENETUNREACH), false},
+		{"other", errors.New("x509: certificate signed by unknown authority"), false},
+	}
+	for _, tc := range tt {
+		if got := temporary(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	defer func(d time.Duration) { IdleTimeout = d }(IdleTimeout)
+	IdleTimeout = 50 * time.Millisecond
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := w.(http.Flusher)
+		// A slow but steady body must not time out.
+		for i := 0; i < 5; i++ {
+			io.WriteString(w, "data")
+			f.Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+		if r.URL.Path == "/stall" {
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer srv.Close()
+
+	res, err := Get(srv.URL + "/steady")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil || string(buf) != strings.Repeat("data", 5) {
+		t.Errorf("steady body = %q, %v", buf, err)
+	}
+
+	res, err = Get(srv.URL + "/stall")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.ReadAll(res.Body)
+	res.Body.Close()
+	if err == nil {
+		t.Fatal("expected stalled body to time out")
+	}
+	if !strings.Contains(err.Error(), "no data received") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}